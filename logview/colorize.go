@@ -0,0 +1,85 @@
+package logview
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A Colorizer styles a line for display, returning it with ANSI styling
+// added. It's applied at render time; the stored line is left unchanged.
+type Colorizer interface {
+	Colorize(line string) string
+}
+
+// ColorizerFunc adapts an ordinary function to the [Colorizer] interface.
+type ColorizerFunc func(line string) string
+
+func (f ColorizerFunc) Colorize(line string) string { return f(line) }
+
+var (
+	// LevelColorizer colors log levels, like ERROR or WARN, by severity.
+	LevelColorizer Colorizer = ColorizerFunc(colorizeLevels)
+
+	// JSONColorizer colors the keys and values of lines that are JSON
+	// objects or arrays. Other lines are left as they are.
+	JSONColorizer Colorizer = ColorizerFunc(colorizeJSON)
+)
+
+var (
+	levelRe     = regexp.MustCompile(`\b(?:(FATAL|PANIC|ERROR|ERR)|(WARNING|WARN)|(INFO)|(DEBUG|TRACE))\b`)
+	levelStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("11")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("12")),
+		lipgloss.NewStyle().Faint(true),
+	}
+)
+
+func colorizeLevels(line string) string {
+	return replaceSubmatches(levelRe, line, levelStyles)
+}
+
+var (
+	// jsonTokenRe matches escape sequences first, without capturing them, so
+	// that the digits in their parameters are never taken for numbers.
+	jsonTokenRe = regexp.MustCompile(hyperlinkRe.String() + "|" + escapeRe.String() +
+		`|("(?:[^"\\]|\\.)*")\s*:|("(?:[^"\\]|\\.)*")|(-?\b\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b|\b(?:true|false|null)\b)`)
+	jsonStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("14")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("10")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("13")),
+	}
+)
+
+func colorizeJSON(line string) string {
+	text := strings.TrimLeft(stripEscapes(hyperlinkRe.ReplaceAllString(line, "")), " \t")
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return line
+	}
+	return replaceSubmatches(jsonTokenRe, line, jsonStyles)
+}
+
+// replaceSubmatches renders each match of re in line with the style at the
+// index of whichever of its capture groups matched. Matches that capture
+// nothing are left as they are.
+func replaceSubmatches(re *regexp.Regexp, line string, styles []lipgloss.Style) string {
+	var (
+		out   []byte
+		start = 0
+	)
+	for _, match := range re.FindAllStringSubmatchIndex(line, -1) {
+		for group := range styles {
+			from, to := match[2+2*group], match[3+2*group]
+			if from < 0 {
+				continue
+			}
+			out = append(out, line[start:from]...)
+			out = append(out, styles[group].Render(line[from:to])...)
+			start = to
+			break
+		}
+	}
+	return string(append(out, line[start:]...))
+}
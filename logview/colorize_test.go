@@ -0,0 +1,35 @@
+package logview
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestColorizeJSON(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.ANSI256)
+
+	number := jsonStyles[2].Render
+	tests := []struct{ line, want string }{
+		// not JSON
+		{"\x1b[31mred\x1b[0m GET /v2", "\x1b[31mred\x1b[0m GET /v2"},
+		{"took 12ms", "took 12ms"},
+		// escape sequences survive, and numbers inside words aren't colored
+		{"\x1b[2m[404, v2]\x1b[0m", "\x1b[2m[" + number("404") + ", v2]\x1b[0m"},
+		{"\x1b]8;;http://x/1\x1b\\[1]\x1b]8;;\x1b\\", "\x1b]8;;http://x/1\x1b\\[" + number("1") + "]\x1b]8;;\x1b\\"},
+		{"[-1.5e3, null, nullable]", "[" + number("-1.5e3") + ", " + number("null") + ", nullable]"},
+	}
+	for _, tt := range tests {
+		if got := JSONColorizer.Colorize(tt.line); got != tt.want {
+			t.Errorf("Colorize(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	line := `{"status":200}`
+	want := "{" + jsonStyles[0].Render(`"status"`) + ":" + number("200") + "}"
+	if got := JSONColorizer.Colorize(line); got != want {
+		t.Errorf("Colorize(%q) = %q, want %q", line, got, want)
+	}
+}
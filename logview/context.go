@@ -0,0 +1,120 @@
+package logview
+
+import "slices"
+
+// contextSeparator is shown between groups of lines that aren't adjacent in
+// the log, when showing context around matches.
+const contextSeparator = "--"
+
+// SetFilterContext sets how many lines before and after each line passing
+// the filter are shown with it, like `grep -C`. Context lines are shown
+// without highlighting, and groups of lines that aren't adjacent in the log
+// are separated by a line in the ContextSeparator style.
+func (m *Model) SetFilterContext(before, after int) {
+	m.contextBefore, m.contextAfter = max(0, before), max(0, after)
+	m.searchNow()
+}
+
+// showingContext reports whether the view includes context around the
+// lines passing the filter.
+func (m *Model) showingContext() bool {
+	return m.filtering() && (m.contextBefore > 0 || m.contextAfter > 0)
+}
+
+// isContext reports whether the line at lineno is only in the view as
+// context for a line passing the filter.
+func (m *Model) isContext(lineno int) bool {
+	_, ok := m.contextLines[lineno]
+	return ok
+}
+
+// setFiltered sets the view to the lines at the given indices, which pass
+// the filter, and any context around them.
+func (m *Model) setFiltered(matches []int) {
+	m.filtered, m.contextLines = matches, nil
+	if !m.showingContext() || len(matches) == 0 {
+		return
+	}
+
+	n := m.lineCount()
+	m.filtered = make([]int, 0, len(matches))
+	m.contextLines = make(map[int]struct{})
+	next := 0
+	for _, i := range matches {
+		// overlapping context is only included once
+		for j := max(next, i-m.contextBefore); j <= min(n-1, i+m.contextAfter); j++ {
+			m.filtered = append(m.filtered, j)
+			m.contextLines[j] = struct{}{}
+		}
+		// it may have been added as context for an earlier match
+		delete(m.contextLines, i)
+		next = max(next, min(n-1, i+m.contextAfter)+1)
+	}
+}
+
+// addMatches adds the lines at the given indices, which pass the filter,
+// to the view, along with any context around them. Lines from index from
+// onwards are new, so they may be context for an earlier match.
+func (m *Model) addMatches(found []int, from int) {
+	if !m.showingContext() {
+		for _, i := range found {
+			m.addFiltered(i, false)
+		}
+		return
+	}
+
+	n := m.lineCount()
+	if last := m.lastMatch(); last >= 0 {
+		for j := from; j <= min(n-1, last+m.contextAfter); j++ {
+			m.addFiltered(j, true)
+		}
+	}
+	for _, i := range found {
+		for j := max(0, i-m.contextBefore); j <= min(n-1, i+m.contextAfter); j++ {
+			m.addFiltered(j, j != i)
+		}
+	}
+}
+
+// addFiltered adds the line at index i to the view, if it isn't already.
+// A line passing the filter is never demoted to context.
+func (m *Model) addFiltered(i int, context bool) {
+	j, found := len(m.filtered), false
+	if j > 0 && m.filtered[j-1] >= i {
+		j, found = slices.BinarySearch(m.filtered, i)
+	}
+	if !found {
+		m.filtered = slices.Insert(m.filtered, j, i)
+	}
+
+	switch {
+	case !context:
+		delete(m.contextLines, i)
+	case !found:
+		if m.contextLines == nil {
+			m.contextLines = make(map[int]struct{})
+		}
+		m.contextLines[i] = struct{}{}
+	}
+}
+
+// lastMatch returns the index of the last line in the view passing the
+// filter, rather than being context, or -1 if there isn't one.
+func (m *Model) lastMatch() int {
+	for pos := len(m.filtered) - 1; pos >= 0; pos-- {
+		if i := m.filtered[pos]; !m.isContext(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// startsGroup reports whether the line at position pos in the view isn't
+// adjacent in the log to the one before it, when showing context.
+func (m *Model) startsGroup(pos int) bool {
+	if !m.showingContext() || pos == 0 {
+		return false
+	}
+	gap := m.viewIndex(pos) - m.viewIndex(pos-1)
+	return gap != 1 && gap != -1
+}
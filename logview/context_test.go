@@ -0,0 +1,41 @@
+package logview
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterContext(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 12 {
+		if i == 2 || i == 4 || i == 10 {
+			m.WriteLine(fmt.Sprintf("match %d", i))
+		} else {
+			m.WriteLine(fmt.Sprint(i))
+		}
+	}
+	m.SetQuery("match")
+	m.SetFilterContext(1, 1)
+
+	// the contexts of 2 and 4 overlap, so they're merged into one group
+	assertScreen(t, m, 10, 10, "1", "match 2", "3", "match 4", "5", "--", "9", "match 10", "11", "")
+	if m.isContext(2) || !m.isContext(3) {
+		t.Errorf("isContext(2), isContext(3) = %v, %v, want false, true", m.isContext(2), m.isContext(3))
+	}
+	if got, want := m.FilteredCount(), 3; got != want {
+		t.Errorf("FilteredCount() = %d, want %d", got, want)
+	}
+
+	// a match is never shown as context for another
+	m.SetFilterContext(2, 0)
+	assertScreen(t, m, 10, 8, "0", "1", "match 2", "3", "match 4", "--", "8", "9")
+	if m.isContext(2) {
+		t.Error("isContext(2) = true for a match within another's context")
+	}
+
+	// lines written later are context for an earlier match
+	m.SetFilterContext(0, 2)
+	m.WriteLine("12")
+	m.WriteLine("13")
+	assertScreen(t, m, 10, 9, "match 2", "3", "match 4", "5", "6", "--", "match 10", "11", "12")
+}
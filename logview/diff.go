@@ -0,0 +1,114 @@
+package logview
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffTokenRe splits a line into the tokens that are compared when
+// highlighting differences: escape sequences, words, runs of whitespace,
+// and any other single character.
+var diffTokenRe = regexp.MustCompile(escapeRe.String() + `|[\p{L}\p{N}_]+|\s+|.`)
+
+// maxDiffCells bounds the size of the table used to diff two lines, once
+// their common prefix and suffix are trimmed. Past it, everything in
+// between is treated as changed.
+const maxDiffCells = 1 << 18
+
+// SetDiffHighlight sets whether the words of each line that differ from
+// the line before it in the view are rendered in the Diff style, which
+// makes the values that change between otherwise repeated lines stand out.
+// Lines with less than half their words in common with the one before are
+// left alone.
+func (m *Model) SetDiffHighlight(diff bool) { m.diffHighlight = diff }
+
+// prevInView returns the index of the line before the one at index lineno
+// in the view, in the order they were written, or -1 if there isn't one.
+func (m *Model) prevInView(lineno int) int {
+	if !m.filtering() {
+		return lineno - 1
+	}
+	pos, _ := slices.BinarySearch(m.filtered, lineno)
+	if pos == 0 {
+		return -1
+	}
+	return m.filtered[pos-1]
+}
+
+// highlightDiff renders the tokens of line that aren't in prev in style.
+func highlightDiff(style lipgloss.Style, line, prev string) string {
+	tokens := diffTokenRe.FindAllString(line, -1)
+	changed := diffTokens(tokens, diffTokenRe.FindAllString(prev, -1))
+	if changed == nil {
+		return line
+	}
+
+	var b strings.Builder
+	for i, token := range tokens {
+		if changed[i] && strings.TrimSpace(token) != "" && !escapeRe.MatchString(token) {
+			token = style.Render(token)
+		}
+		b.WriteString(token)
+	}
+	return b.String()
+}
+
+// diffTokens reports which of the tokens in a aren't part of the longest
+// common subsequence of a and b, or returns nil if a is the same as b or
+// has less than half its tokens in common with it.
+func diffTokens(a, b []string) []bool {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	changed := make([]bool, len(a))
+	common := prefix + suffix
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(am)*len(bm) > maxDiffCells {
+		for i := range am {
+			changed[prefix+i] = true
+		}
+	} else {
+		// lcs[i*w+j] is the length of the longest common subsequence of
+		// am[i:] and bm[j:]
+		w := len(bm) + 1
+		lcs := make([]int32, (len(am)+1)*w)
+		for i := len(am) - 1; i >= 0; i-- {
+			for j := len(bm) - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+				} else {
+					lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(am) {
+			switch {
+			case j < len(bm) && am[i] == bm[j]:
+				common++
+				i++
+				j++
+			case j < len(bm) && lcs[(i+1)*w+j] < lcs[i*w+j+1]:
+				j++
+			default:
+				changed[prefix+i] = true
+				i++
+			}
+		}
+	}
+
+	if common == len(a) || common*2 < len(a) {
+		return nil
+	}
+	return changed
+}
@@ -0,0 +1,60 @@
+package logview
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffMark marks changed tokens, since styles aren't rendered in tests.
+var diffMark = lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" })
+
+func TestHighlightDiff(t *testing.T) {
+	tests := []struct{ line, prev, want string }{
+		{"progress 41% eta 12s", "progress 40% eta 13s", "progress [41]% eta [12s]"},
+		{"same line", "same line", "same line"},
+		// words added or removed in the middle
+		{"user bob logged in from home", "user bob logged in", "user bob logged in [from] [home]"},
+		{"a b d", "a b c d", "a b d"},
+		// lines with little in common aren't diffed
+		{"completely different", "nothing alike here", "completely different"},
+		{"first", "", "first"},
+		// escape sequences are compared, but never styled
+		{"\x1b[32mok\x1b[0m 7", "\x1b[32mok\x1b[0m 6", "\x1b[32mok\x1b[0m [7]"},
+	}
+	for _, tt := range tests {
+		if got := highlightDiff(diffMark, tt.line, tt.prev); got != tt.want {
+			t.Errorf("highlightDiff(%q, %q) = %q, want %q", tt.line, tt.prev, got, tt.want)
+		}
+	}
+}
+
+func TestDiffHighlight(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("GET /a 200 12ms\nGET /a 200 15ms\nPOST /b 500 3ms\nGET /a 200 11ms\n")
+	m.SetDiffHighlight(true)
+
+	styles := *defaultStyles
+	styles.Diff = diffMark
+	render := func() []string {
+		rows := strings.Split(stripEscapes(m.renderLog(&styles, 30, 4)), "\n")
+		for i, row := range rows {
+			rows[i] = strings.TrimRight(row, " ")
+		}
+		return rows
+	}
+
+	want := []string{"GET /a 200 12ms", "GET /a 200 [15ms]", "[POST] /[b] [500] [3ms]", "[GET] /[a] [200] [11ms]"}
+	if got := render(); !slices.Equal(got, want) {
+		t.Errorf("screen =\n%q\nwant\n%q", got, want)
+	}
+
+	// lines are compared with the one before them in the view
+	m.SetQuery("GET")
+	want = []string{"GET /a 200 12ms", "GET /a 200 [15ms]", "GET /a 200 [11ms]"}
+	if got := render(); !slices.Equal(got, want) {
+		t.Errorf("filtered screen =\n%q\nwant\n%q", got, want)
+	}
+}
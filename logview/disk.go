@@ -0,0 +1,114 @@
+package logview
+
+import (
+	"os"
+	"sync"
+)
+
+// defaultDiskBackingMaxLines is the number of lines kept in memory when disk
+// backing is enabled without saying how many.
+const defaultDiskBackingMaxLines = 10_000
+
+// diskStore holds the oldest lines of a log in a temporary file, reading
+// them back on demand, so that memory stays bounded for huge logs.
+type diskStore struct {
+	dir  string
+	file *os.File
+
+	// maxLines is the number of lines kept in memory. Older lines are
+	// spilled to disk in batches once twice this many have accumulated.
+	maxLines int
+
+	// failure records the first error reading or writing file. It's shared
+	// by the copies of the store in snapshots, which may be read from
+	// other goroutines.
+	failure *diskFailure
+
+	// offsets[i] is the position in file where line i starts. The final
+	// entry is the end of the last line.
+	offsets []int64
+}
+
+func (d *diskStore) len() int { return max(0, len(d.offsets)-1) }
+
+// append writes lines to the end of the store. If the write fails, the
+// store is left unchanged and the lines should be kept in memory.
+func (d *diskStore) append(lines []string) error {
+	if d.file == nil {
+		file, err := os.CreateTemp(d.dir, "logview-*")
+		if err != nil {
+			d.failure.record(err)
+			return err
+		}
+		d.file, d.offsets = file, []int64{0}
+	}
+
+	var (
+		buf     []byte
+		end     = d.offsets[len(d.offsets)-1]
+		offsets = make([]int64, 0, len(lines))
+	)
+	for _, line := range lines {
+		buf = append(buf, line...)
+		end += int64(len(line))
+		offsets = append(offsets, end)
+	}
+	if _, err := d.file.WriteAt(buf, d.offsets[len(d.offsets)-1]); err != nil {
+		d.failure.record(err)
+		return err
+	}
+	d.offsets = append(d.offsets, offsets...)
+	return nil
+}
+
+// line reads back line i. If it can't be read, the error is recorded and
+// the line is displayed as empty.
+func (d *diskStore) line(i int) string {
+	line, err := d.read(i)
+	if err != nil {
+		d.failure.record(err)
+	}
+	return line
+}
+
+// read reads back line i.
+func (d *diskStore) read(i int) (string, error) {
+	buf := make([]byte, d.offsets[i+1]-d.offsets[i])
+	if _, err := d.file.ReadAt(buf, d.offsets[i]); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// err returns the first error reading or writing the store, if there's
+// been one.
+func (d *diskStore) err() error {
+	if d.failure == nil {
+		return nil
+	}
+	d.failure.mu.Lock()
+	defer d.failure.mu.Unlock()
+	return d.failure.err
+}
+
+type diskFailure struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *diskFailure) record(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+// close removes the backing file.
+func (d *diskStore) close() error {
+	if d.file == nil {
+		return nil
+	}
+	d.file.Close()
+	return os.Remove(d.file.Name())
+}
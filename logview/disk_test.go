@@ -0,0 +1,59 @@
+package logview
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiskBacking(t *testing.T) {
+	m := New(WithDiskBacking(t.TempDir(), 3), WithStartAtHead)
+	defer m.Close()
+	var want []string
+	for i := range 10 {
+		want = append(want, fmt.Sprint("line ", i))
+	}
+	m.Write(strings.Join(want, "\n") + "\npartial")
+
+	if got := len(m.lines); got >= 6 {
+		t.Errorf("%d lines kept in memory, want fewer than 6", got)
+	}
+	if got, want := m.String(), strings.Join(want, "\n")+"\npartial"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	name := filepath.Join(t.TempDir(), "saved")
+	if err := m.SaveToFile(name); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(saved), strings.Join(want, "\n")+"\npartial"; got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+	if err := m.DiskErr(); err != nil {
+		t.Errorf("DiskErr() = %v", err)
+	}
+}
+
+func TestDiskReadError(t *testing.T) {
+	m := New(WithDiskBacking(t.TempDir(), 2), WithStartAtHead)
+	defer m.Close()
+	m.Write("a\nb\nc\nd\ne\n")
+	m.disk.file.Close()
+
+	screen := m.RenderAt(20, 6)
+	if m.DiskErr() == nil {
+		t.Error("DiskErr() = nil after reading from a closed file")
+	}
+	if !strings.Contains(screen, "[disk error]") {
+		t.Errorf("screen = %q, want [disk error] in the statusbar", screen)
+	}
+	if err := m.SaveToFile(filepath.Join(t.TempDir(), "saved")); err == nil {
+		t.Error("SaveToFile() succeeded without the lines on disk")
+	}
+}
@@ -0,0 +1,92 @@
+package logview
+
+import (
+	"regexp"
+	"strings"
+)
+
+// extractSeparator separates the columns of extracted lines.
+const extractSeparator = "  "
+
+// SetExtract displays each line matching pattern as a table row made of
+// the pattern's capture groups, aligned into columns sized to fit the lines
+// in the viewport. If the pattern has no groups, the whole match is shown.
+// An empty pattern displays whole lines again.
+//
+// Lines that don't match are shown as-is, unless hidden with
+// [Model.SetShowUnextracted]. Searching still matches the whole line.
+func (m *Model) SetExtract(pattern string) error {
+	if pattern == "" {
+		m.extractRe = nil
+		m.searchNow()
+		return nil
+	}
+	extractRe, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	m.extractRe = extractRe
+	m.searchNow()
+	return nil
+}
+
+// SetShowUnextracted sets whether lines not matching the pattern given to
+// [Model.SetExtract] are shown as-is, or hidden. The default is to show
+// them.
+func (m *Model) SetShowUnextracted(show bool) {
+	m.hideUnextracted = !show
+	m.searchNow()
+}
+
+// extractFields returns the values of the extract pattern's groups in line,
+// or nil if line doesn't match.
+func (m *Model) extractFields(line string) []string {
+	match := m.extractRe.FindStringSubmatch(line)
+	if len(match) > 1 {
+		return match[1:]
+	}
+	return match
+}
+
+// extractColumns renders line as a row of its extracted fields, padded to
+// the current column widths.
+func (m *Model) extractColumns(line string) string {
+	fields := m.extractFields(line)
+	if fields == nil {
+		return line
+	}
+	var b strings.Builder
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString(extractSeparator)
+		}
+		b.WriteString(field)
+		if i < len(fields)-1 && i < len(m.extractWidths) {
+			b.WriteString(strings.Repeat(" ", max(0, m.extractWidths[i]-stringWidth(field))))
+		}
+	}
+	return b.String()
+}
+
+// sizeExtractColumns sizes the columns of extracted lines to fit the widest
+// value among the lines that could be in a viewport of the given height.
+func (m *Model) sizeExtractColumns(height int) {
+	m.extractWidths = m.extractWidths[:0]
+	if m.extractRe == nil {
+		return
+	}
+
+	n := m.viewLen()
+	lo := max(0, m.scrollPosition)
+	if m.scrollPosition < 0 && !m.reverse {
+		lo = max(0, n-height)
+	}
+	for pos := lo; pos < min(n, lo+height); pos++ {
+		for i, field := range m.extractFields(m.line(m.viewIndex(pos))) {
+			if i == len(m.extractWidths) {
+				m.extractWidths = append(m.extractWidths, 0)
+			}
+			m.extractWidths[i] = max(m.extractWidths[i], stringWidth(field))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package logview
+
+import "testing"
+
+func TestExtract(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("GET /a 200 12ms\nstarting up\nPOST /longer 500 3ms\n")
+
+	if err := m.SetExtract(`^(\w+) (\S+) (\d+)`); err != nil {
+		t.Fatal(err)
+	}
+	assertScreen(t, m, 30, 3,
+		"GET   /a       200",
+		"starting up",
+		"POST  /longer  500",
+	)
+
+	m.SetShowUnextracted(false)
+	assertScreen(t, m, 30, 3,
+		"GET   /a       200",
+		"POST  /longer  500",
+		"",
+	)
+
+	// columns fit the lines in the viewport
+	m.ScrollTo(1)
+	assertScreen(t, m, 30, 1, "POST  /longer  500")
+	m.ScrollTo(0)
+	assertScreen(t, m, 30, 1, "GET  /a  200")
+
+	// without groups, the whole match is shown
+	if err := m.SetExtract(`\d+ms`); err != nil {
+		t.Fatal(err)
+	}
+	assertScreen(t, m, 30, 3, "12ms", "3ms", "")
+
+	if err := m.SetExtract("("); err == nil {
+		t.Error("SetExtract accepted an invalid pattern")
+	}
+
+	if err := m.SetExtract(""); err != nil {
+		t.Fatal(err)
+	}
+	assertScreen(t, m, 30, 3, "GET /a 200 12ms", "starting up", "POST /longer 500 3ms")
+}
@@ -0,0 +1,120 @@
+package logview
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// SetFieldExtractor sets how lines are decoded into named fields for
+// [Model.FilterByField]. It returns nil for lines that can't be decoded. It
+// may be called from another goroutine while filtering in the background,
+// so it mustn't refer to anything that changes. A nil extractor decodes
+// lines as JSON objects with [JSONFields], which is the default.
+func (m *Model) SetFieldExtractor(extract func(line string) map[string]string) {
+	m.fieldExtractor = extract
+	m.highlightCache = nil
+	m.searchNow()
+}
+
+// FilterByField narrows the view to lines whose field key, as decoded by
+// the field extractor, matches pattern, in addition to any query. Lines
+// without the field, including those that can't be decoded, aren't shown.
+// Matches within the field's value are highlighted when there's no query
+// or find to highlight instead. An empty key or pattern stops filtering on
+// fields.
+func (m *Model) FilterByField(key, pattern string) error {
+	if key == "" || pattern == "" {
+		m.fieldKey, m.fieldRe = "", nil
+		m.searchNow()
+		return nil
+	}
+	fieldRe, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	m.fieldKey, m.fieldRe = key, fieldRe
+	m.searchNow()
+	return nil
+}
+
+// JSONFields decodes line as a JSON object, returning its top-level
+// fields. String values are unquoted; anything else is left as JSON. It
+// returns nil if line isn't a JSON object.
+func JSONFields(line string) map[string]string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &raw); err != nil || raw == nil {
+		return nil
+	}
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		// null would unmarshal into a string too, as ""
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil && string(value) != "null" {
+			fields[key] = s
+		} else {
+			fields[key] = string(value)
+		}
+	}
+	return fields
+}
+
+// fieldMatcher returns a function reporting whether the field being
+// filtered on in a line matches, or nil if not filtering on a field. Like
+// [Model.lineMatcher], it doesn't refer back to the model.
+func (m *Model) fieldMatcher() func(line string) bool {
+	if m.fieldRe == nil {
+		return nil
+	}
+	extract, key, fieldRe := m.extractFieldsFunc(), m.fieldKey, m.fieldRe
+	return func(line string) bool {
+		value, ok := extract(line)[key]
+		return ok && fieldRe.MatchString(value)
+	}
+}
+
+// extractFieldsFunc returns the field extractor.
+func (m *Model) extractFieldsFunc() func(line string) map[string]string {
+	if m.fieldExtractor == nil {
+		return JSONFields
+	}
+	return m.fieldExtractor
+}
+
+// countMatches returns how many times re matches line, only counting
+// matches within the field being filtered on if re is the field pattern.
+func (m *Model) countMatches(re *regexp.Regexp, line string) int {
+	if re == m.fieldRe {
+		return len(m.fieldMatches(line, nil))
+	}
+	return len(re.FindAllStringIndex(m.matchText(line), -1))
+}
+
+// fieldMatches returns where the field pattern matches within the value of
+// the field being filtered on, as found in line after its key. Nothing is
+// highlighted if the value isn't written in line as it was decoded, such
+// as a JSON string with escaped characters.
+func (m *Model) fieldMatches(line string, escapes [][]int) []Match {
+	value, ok := m.extractFieldsFunc()(m.matchText(line))[m.fieldKey]
+	if !ok || value == "" {
+		return nil
+	}
+	from := 0
+	if i := strings.Index(line, m.fieldKey); i >= 0 {
+		from = i + len(m.fieldKey)
+	}
+	start := strings.Index(line[from:], value)
+	if start < 0 {
+		return nil
+	}
+	start += from
+
+	var matches []Match
+	for _, loc := range m.fieldRe.FindAllStringIndex(value, -1) {
+		loc = []int{start + loc[0], start + loc[1]}
+		if !overlapsAny(loc, escapes) {
+			matches = append(matches, Match{loc[0], loc[1]})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,91 @@
+package logview
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFilterByField(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write(`{"msg":"error budget ok","level":"info"}` + "\n" +
+		`{"msg":"disk full","level":"error"}` + "\n" +
+		"level=error but not JSON\n" +
+		`{"msg":"no level"}` + "\n" +
+		`{"level":"ERROR","code":500}` + "\n")
+
+	if err := m.FilterByField("level", "(?i)^error$"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.FilteredIndices(), []int{1, 4}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+
+	// only the field's value is highlighted, not the same text elsewhere
+	if got, want := m.Matches(1), []Match{{28, 33}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(1) = %v, want %v", got, want)
+	}
+
+	// values that aren't strings are matched as JSON
+	if err := m.FilterByField("code", "^5"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.FilteredIndices(), []int{4}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+
+	if err := m.FilterByField("level", "("); err == nil {
+		t.Error("FilterByField accepted an invalid pattern")
+	}
+	if err := m.FilterByField("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.FilteredCount(), 5; got != want {
+		t.Errorf("FilteredCount() = %d, want %d without a field filter", got, want)
+	}
+}
+
+func TestFieldExtractor(t *testing.T) {
+	m := New()
+	m.Write("level=error msg=a\nlevel=info msg=b\n{\"level\":\"error\"}\n")
+
+	// a logfmt-ish extractor
+	m.SetFieldExtractor(func(line string) map[string]string {
+		fields := make(map[string]string)
+		for _, kv := range strings.Fields(line) {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				fields[k] = v
+			}
+		}
+		return fields
+	})
+	if err := m.FilterByField("level", "error"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.FilteredIndices(), []int{0}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+
+	m.SetFieldExtractor(nil)
+	if got, want := m.FilteredIndices(), []int{2}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v decoding JSON", got, want)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	got := JSONFields(`{"s":"a\"b","n":1.5,"b":true,"o":{"x":1},"z":null}`)
+	want := map[string]string{"s": `a"b`, "n": "1.5", "b": "true", "o": `{"x":1}`, "z": "null"}
+	if len(got) != len(want) {
+		t.Fatalf("JSONFields() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("JSONFields()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+	for _, line := range []string{"not json", "[1,2]", "null", ""} {
+		if got := JSONFields(line); got != nil {
+			t.Errorf("JSONFields(%q) = %v, want nil", line, got)
+		}
+	}
+}
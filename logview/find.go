@@ -0,0 +1,72 @@
+package logview
+
+import (
+	"regexp"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// The query has two tiers: the filter, which narrows the view to the lines
+// matching it, and the find, which highlights matches within the view and
+// is what n and N move between. Without a find, the filter is highlighted
+// and moved between instead.
+
+// SetFind sets the pattern to find within the view, without narrowing it.
+// An empty pattern clears it, so that the filter is highlighted again.
+func (m *Model) SetFind(query string) {
+	m.findInput.SetValue(query)
+	m.applyFind()
+}
+
+// Find returns the pattern being found within the view.
+func (m *Model) Find() string {
+	return m.findInput.Value()
+}
+
+// OpenFind focuses the search bar with an empty find, as if "?" had been
+// pressed, remembering the current find in case it's cancelled.
+func (m *Model) OpenFind() {
+	m.prevFind = m.Find()
+	m.SetFind("")
+	m.editingFind = true
+	m.SetFocus(FocusSearchBar)
+}
+
+// SetFindPrompt sets the prompt shown before the find in the statusbar,
+// which is "?" by default.
+func (m *Model) SetFindPrompt(prompt string) { m.findInput.Prompt = prompt }
+
+// applyFind compiles the current find. If it's momentarily invalid while
+// being typed, the last valid one is kept.
+func (m *Model) applyFind() {
+	m.matchTotal = 0
+	query := m.findInput.Value()
+	if query == "" {
+		m.findRe = nil
+	} else if findRe, err := m.compileQuery(query); err == nil {
+		m.findRe = findRe
+	}
+}
+
+// highlightRe returns the pattern that's highlighted and moved between:
+// the find, or the query if there isn't one, or else the filter rule's
+// include pattern or the field pattern.
+func (m *Model) highlightRe() *regexp.Regexp {
+	switch {
+	case m.findRe != nil:
+		return m.findRe
+	case m.queryRe != nil:
+		return m.queryRe
+	case m.filterInclude != nil:
+		return m.filterInclude
+	}
+	return m.fieldRe
+}
+
+// bar returns the input being typed into in the search bar.
+func (m *Model) bar() *textinput.Model {
+	if m.editingFind {
+		return m.findInput
+	}
+	return m.input
+}
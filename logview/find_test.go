@@ -0,0 +1,164 @@
+package logview
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// typeSearch presses key to open the search bar, types text into it, and
+// presses enter, waiting for any search it starts.
+func typeSearch(m *Model, key, text string) {
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	if cmd := m.TypeSearch(text); cmd != nil {
+		m.Update(cmd())
+	}
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func TestFilterAndFind(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.Write("GET /a 200\nPOST /b 500\nGET /c 500\nGET /d 200\nGET /e 500\n")
+
+	typeSearch(m, "/", "GET")
+	typeSearch(m, "?", "500")
+	if got, want := m.Query(), "GET"; got != want {
+		t.Errorf("Query() = %q, want %q", got, want)
+	}
+	if got, want := m.Find(), "500"; got != want {
+		t.Errorf("Find() = %q, want %q", got, want)
+	}
+
+	// the find highlights within the filter without narrowing it further
+	assertScreen(t, m, 40, 5, "GET /a 200", "GET /c 500", "GET /d 200", "GET /e 500", "1 of 4  /GET (4) ?500")
+
+	// and n moves between its matches, skipping the filtered out POST
+	var visited []int
+	for range 3 {
+		m.NextMatch()
+		visited = append(visited, m.CurrentLine())
+	}
+	if want := []int{2, 4, 4}; !slices.Equal(visited, want) {
+		t.Errorf("n visited lines %v, want %v", visited, want)
+	}
+
+	// clearing the find highlights the filter again
+	m.SetFind("")
+	if got := m.highlightRe(); got == nil || got.String() != m.queryRe.String() {
+		t.Errorf("highlightRe() = %v, want the filter", got)
+	}
+}
+
+func TestSearchPreview(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.Write("error a\nok\nerror b\n")
+
+	// mark the preview, since styles aren't rendered in tests
+	styles := *defaultStyles
+	styles.SearchPreview = lipgloss.NewStyle().Transform(func(s string) string { return "<" + s + ">" })
+	status := func() string {
+		rows := strings.Split(stripEscapes(m.Render(&styles, 30, 3)), "\n")
+		return strings.TrimRight(rows[len(rows)-1], " ")
+	}
+
+	m.OpenSearch()
+	m.Update(m.TypeSearch("err")())
+	if got, want := status(), "1 of 2  /<err>  <~2>"; got != want {
+		t.Errorf("status while typing = %q, want %q", got, want)
+	}
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, want := status(), "1 of 2  /err (2)"; got != want {
+		t.Errorf("status once applied = %q, want %q", got, want)
+	}
+
+	m.OpenFind()
+	m.TypeSearch("b")
+	if got, want := status(), "1 of 2  /err (2) ?<b>"; !strings.HasPrefix(got, want) {
+		t.Errorf("status while typing a find = %q, want it to start with %q", got, want)
+	}
+}
+
+func TestFreezeOnSearch(t *testing.T) {
+	m := New(WithoutStatusbar)
+	m.SetFreezeOnSearch(true)
+	m.Write("1\n2\n3\n")
+	m.RenderAt(10, 2)
+
+	// writes while the search bar is open don't move the viewport
+	m.OpenSearch()
+	m.Write("4\n5\n")
+	assertScreen(t, m, 10, 2, "2", "3")
+
+	// and following resumes once it's closed
+	m.CloseSearch(true)
+	assertScreen(t, m, 10, 2, "4", "5")
+	m.Write("6\n")
+	assertScreen(t, m, 10, 2, "5", "6")
+
+	// unless it's not frozen
+	m.SetFreezeOnSearch(false)
+	m.OpenSearch()
+	m.Write("7\n")
+	assertScreen(t, m, 10, 2, "6", "7")
+}
+
+func TestSearchStatusBlurred(t *testing.T) {
+	m := New()
+	m.Write("ERROR a\nok\nERROR b 500\n")
+
+	m.OpenSearch()
+	m.Update(m.TypeSearch("ERROR")())
+	focused := stripEscapes(m.RenderSearchStatus())
+
+	m.CloseSearch(true)
+	if got, want := stripEscapes(m.RenderSearchStatus()), "/ERROR (2)"; got != want {
+		t.Errorf("blurred status = %q, want %q", got, want)
+	}
+	if want := "/ERROR  ~2"; focused != want {
+		t.Errorf("focused status = %q, want %q, with the cursor after the query", focused, want)
+	}
+
+	m.OpenFind()
+	m.TypeSearch("500")
+	m.CloseSearch(true)
+	if got, want := stripEscapes(m.RenderSearchStatus()), "/ERROR (2) ?500"; got != want {
+		t.Errorf("blurred status = %q, want %q", got, want)
+	}
+}
+
+func TestPasteQuery(t *testing.T) {
+	m := New()
+	m.Write("BEGIN\nbody\nEND\n")
+	m.SetMultilineMatch(true)
+
+	m.OpenSearch()
+	paste := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("BEGIN\r\nbody\nEND\n"), Paste: true}
+	m.handleKey(paste)
+	m.CloseSearch(true)
+
+	// the line breaks are escaped, and the trailing one dropped
+	if got, want := m.Query(), `BEGIN\nbody\nEND`; got != want {
+		t.Errorf("Query() = %q, want %q", got, want)
+	}
+	if got, want := m.FilteredCount(), 3; got != want {
+		t.Errorf("FilteredCount() = %d, want %d", got, want)
+	}
+}
+
+func TestPastedQuery(t *testing.T) {
+	tests := []struct{ pasted, want string }{
+		{"plain", "plain"},
+		{"a\nb", `a\nb`},
+		{"a\r\nb\r\n", `a\nb`},
+		{"a\rb", `a\rb`},
+		{"whole line\n", "whole line"},
+	}
+	for _, tt := range tests {
+		if got := string(pastedQuery([]rune(tt.pasted))); got != tt.want {
+			t.Errorf("pastedQuery(%q) = %q, want %q", tt.pasted, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package logview
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// foldRange is a range of line indices, inclusive, displayed as a single
+// summary row.
+type foldRange struct{ start, end int }
+
+// Fold collapses the lines from start to end, inclusive, into a single
+// `… N lines …` row, like an editor's code folding. Ranges overlapping an
+// existing fold are merged with it.
+//
+// Folds only apply to the unfiltered log: filtering searches folded lines
+// too, so matches are never hidden. While only highlighting matches, the
+// summary row counts the matches it hides.
+func (m *Model) Fold(start, end int) {
+	start, end = max(0, start), min(m.lineCount()-1, end)
+	if end <= start {
+		return
+	}
+	defer m.keepViewport()()
+
+	var folds []foldRange
+	for _, f := range m.folds {
+		if f.end < start || f.start > end {
+			folds = append(folds, f)
+			continue
+		}
+		start, end = min(start, f.start), max(end, f.end)
+	}
+	i, _ := slices.BinarySearchFunc(folds, start, func(f foldRange, start int) int {
+		return cmp.Compare(f.start, start)
+	})
+	m.folds = slices.Insert(folds, i, foldRange{start, end})
+}
+
+// Unfold expands the fold containing the line at index line, if any.
+func (m *Model) Unfold(line int) {
+	i := slices.IndexFunc(m.folds, func(f foldRange) bool {
+		return f.start <= line && line <= f.end
+	})
+	if i < 0 {
+		return
+	}
+	defer m.keepViewport()()
+	m.folds = slices.Delete(slices.Clone(m.folds), i, i+1)
+}
+
+// folding reports whether folds affect the current view.
+func (m *Model) folding() bool {
+	return len(m.folds) > 0 && !m.filtering()
+}
+
+// foldAt returns the fold starting at the line at index lineno, if it's
+// displayed in the current view.
+func (m *Model) foldAt(lineno int) (foldRange, bool) {
+	if !m.folding() {
+		return foldRange{}, false
+	}
+	i, ok := slices.BinarySearchFunc(m.folds, lineno, func(f foldRange, lineno int) int {
+		return cmp.Compare(f.start, lineno)
+	})
+	if !ok {
+		return foldRange{}, false
+	}
+	return m.folds[i], true
+}
+
+// foldedLines returns the number of lines hidden by folds.
+func (m *Model) foldedLines() int {
+	var n int
+	for _, f := range m.folds {
+		n += f.end - f.start
+	}
+	return n
+}
+
+// unfoldIndex maps a position among the lines not hidden by folds to a line
+// index.
+func (m *Model) unfoldIndex(i int) int {
+	for _, f := range m.folds {
+		if f.start >= i {
+			break
+		}
+		i += f.end - f.start
+	}
+	return i
+}
+
+// foldSummary renders the row standing in for the lines in f.
+func (m *Model) foldSummary(styles *Styles, f foldRange) string {
+	summary := fmt.Sprintf("… %d lines …", f.end-f.start+1)
+	if re := m.highlightRe(); re != nil {
+		var matches int
+		for i := f.start; i <= f.end; i++ {
+			if m.countMatches(re, m.line(i)) > 0 {
+				matches++
+			}
+		}
+		if matches > 0 {
+			summary = fmt.Sprintf("… %d lines, %d matches …", f.end-f.start+1, matches)
+		}
+	}
+	return styles.Folded.Render(summary)
+}
@@ -0,0 +1,222 @@
+package logview
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxFollowBatch bounds how many chunks are written to the model at once.
+const maxFollowBatch = 4096
+
+// followPollInterval is how often FollowFile checks for more content once
+// it has caught up with the end of the file.
+const followPollInterval = 32 * time.Millisecond
+
+// FollowErrMsg is emitted by the model when a reader being followed with
+// [FollowReader] or [FollowFile] fails.
+type FollowErrMsg struct{ Err error }
+
+// followMsg carries what a followed reader produced since the last one.
+type followMsg struct {
+	stream Stream
+	chunks []string
+	err    error
+	done   bool
+	next   tea.Cmd
+}
+
+// FollowReader returns a command that reads r until EOF, writing its
+// content to the model whose Update handles the resulting messages. Once
+// r is exhausted, the model is marked as being at EOF.
+//
+// Reading stops when ctx is done, without marking the model as being at
+// EOF. A read of r that's already underway, like of a terminal, is waited
+// for first.
+func FollowReader(ctx context.Context, r io.Reader) tea.Cmd {
+	return FollowStream(ctx, StreamStdout, r)
+}
+
+// FollowStream is like [FollowReader], but tags what it reads with stream,
+// like [Model.WriteStream]. For example, a subprocess's stdout and stderr
+// can each be followed with their own command.
+func FollowStream(ctx context.Context, stream Stream, r io.Reader) tea.Cmd {
+	return follow(ctx, stream, r, nil)
+}
+
+// follow reads r in the background for FollowStream, closing closer, if
+// there is one, once it stops.
+func follow(ctx context.Context, stream Stream, r io.Reader, closer io.Closer) tea.Cmd {
+	chunks := make(chan string, maxFollowBatch)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(chunks)
+		if closer != nil {
+			defer closer.Close()
+		}
+		br := bufio.NewReader(r)
+		for {
+			chunk, err := br.ReadString('\n')
+			if chunk != "" {
+				select {
+				case chunks <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					errc <- err
+				}
+				return
+			}
+		}
+	}()
+	return followChunks(ctx, stream, chunks, errc)
+}
+
+// FollowFile is like [FollowReader], but follows the named file like
+// `tail -f`, waiting for more content to be appended instead of stopping
+// at the end of the file. The file is closed once ctx is done.
+func FollowFile(ctx context.Context, name string, opts ...FollowOption) tea.Cmd {
+	var options followOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	file, err := os.Open(name)
+	if err == nil && options.tailLines > 0 {
+		if err = seekLastLines(file, options.tailLines); err != nil {
+			file.Close()
+		}
+	}
+	if err != nil {
+		return func() tea.Msg { return followMsg{err: err, done: true} }
+	}
+	return follow(ctx, StreamStdout, tailReader{ctx, file}, file)
+}
+
+// FollowOption configures [FollowFile]. Options are named Follow*, like
+// [FollowTailLines], to set them apart from the With* options that
+// configure a [Model] in [New].
+type FollowOption func(*followOptions)
+
+type followOptions struct {
+	tailLines int
+}
+
+// FollowTailLines starts following from the last n lines of the file, like
+// `tail -n`, instead of reading the whole thing.
+func FollowTailLines(n int) FollowOption {
+	return func(o *followOptions) { o.tailLines = n }
+}
+
+// tailBlockSize is how much of a file seekLastLines reads at a time.
+const tailBlockSize = 64 * 1024
+
+// seekLastLines seeks file to the start of its last n lines, searching
+// backwards from the end. A final line without a newline counts as a line.
+func seekLastLines(file *os.File, n int) error {
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	block := make([]byte, tailBlockSize)
+	newlines := 0
+	for offset := end; offset > 0; {
+		size := min(offset, tailBlockSize)
+		offset -= size
+		if _, err := file.ReadAt(block[:size], offset); err != nil {
+			return err
+		}
+		for i := size - 1; i >= 0; i-- {
+			if block[i] != '\n' || offset+i == end-1 {
+				// the newline ending the last line doesn't start one
+				continue
+			}
+			if newlines++; newlines == n {
+				_, err := file.Seek(offset+i+1, io.SeekStart)
+				return err
+			}
+		}
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// followChunks returns a command that waits for the next chunk, then
+// collects whatever else has piled up in the meantime, so that a fast
+// reader doesn't flood the program with one message per line.
+func followChunks(ctx context.Context, stream Stream, chunks <-chan string, errc <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		chunk, ok := <-chunks
+		if !ok {
+			if ctx.Err() != nil {
+				// following was stopped, rather than reaching the end
+				return nil
+			}
+			var err error
+			select {
+			case err = <-errc:
+			default:
+			}
+			return followMsg{stream: stream, err: err, done: true}
+		}
+
+		batch := []string{chunk}
+	drain:
+		for len(batch) < maxFollowBatch {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, chunk)
+			default:
+				break drain
+			}
+		}
+		return followMsg{stream: stream, chunks: batch, next: followChunks(ctx, stream, chunks, errc)}
+	}
+}
+
+func (m *Model) handleFollow(msg followMsg) tea.Cmd {
+	for _, chunk := range msg.chunks {
+		m.WriteStream(msg.stream, chunk)
+	}
+	if !msg.done {
+		return msg.next
+	}
+	m.MarkEOF()
+	if msg.err != nil {
+		return func() tea.Msg { return FollowErrMsg{msg.err} }
+	}
+	return nil
+}
+
+// tailReader reads a file, waiting for more to be appended instead of
+// returning io.EOF, until ctx is done.
+type tailReader struct {
+	ctx  context.Context
+	file *os.File
+}
+
+func (t tailReader) Read(p []byte) (int, error) {
+	for {
+		n, err := t.file.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		select {
+		case <-t.ctx.Done():
+			return 0, t.ctx.Err()
+		case <-time.After(followPollInterval):
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package logview
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSeekLastLines(t *testing.T) {
+	long := strings.Repeat("x", tailBlockSize) + "\n"
+	tests := []struct {
+		content string
+		n       int
+		want    string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\nc\n", 5, "a\nb\nc\n"},
+		{"a\nb\nc", 1, "c"},
+		{"a\nb\nc", 5, "a\nb\nc"},
+		{"a", 1, "a"},
+		{"a\n", 2, "a\n"},
+		{"", 1, ""},
+		{"a\n" + long + "b\n", 2, long + "b\n"},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(t.TempDir(), "log")
+		if err := os.WriteFile(name, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := seekLastLines(file, tt.n); err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("last %d lines of %.20q = %.20q, want %.20q", tt.n, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFollowTailLines(t *testing.T) {
+	tests := []struct {
+		content, appended string
+		n                 int
+		want              string
+	}{
+		{"a\nb\nc\n", "", 2, "b\nc"},
+		// the last line is finished once following
+		{"a\nb\nc", "\n", 2, "b\nc"},
+		// the file is shorter than n lines
+		{"a\nb\n", "", 5, "a\nb"},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(t.TempDir(), "log")
+		if err := os.WriteFile(name, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		m := New()
+		ctx, cancel := context.WithCancel(context.Background())
+		cmd := FollowFile(ctx, name, FollowTailLines(tt.n))
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.WriteString(tt.appended); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		// the file is followed until cancelled, so only wait for what's
+		// in it
+		for m.LineCount() < strings.Count(tt.want, "\n")+1 {
+			_, cmd = m.Update(cmd())
+		}
+		cancel()
+		if got := m.String(); got != tt.want {
+			t.Errorf("last %d lines of %q: String() = %q, want %q", tt.n, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFollowReader(t *testing.T) {
+	m := New()
+	cmd := FollowReader(context.Background(), strings.NewReader("a\nb\nc"))
+	for cmd != nil {
+		_, cmd = m.Update(cmd())
+	}
+	if got, want := m.String(), "a\nb\nc"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !m.AtEOF() {
+		t.Error("AtEOF() = false after the reader ended")
+	}
+}
+
+func TestFollowCancel(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(name, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	_, cmd := m.Update(FollowFile(ctx, name)())
+	cancel()
+
+	// once the reading goroutine exits, the command finishes without
+	// a message, rather than waiting for more of the file forever
+	done := make(chan tea.Msg)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if msg != nil {
+			t.Errorf("after cancelling, got %#v, want no message", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("following didn't stop after cancelling")
+	}
+	if m.AtEOF() {
+		t.Error("AtEOF() = true after cancelling")
+	}
+
+	// nor is it stuck sending what nobody's reading
+	lines := strings.Repeat("a\n", 2*maxFollowBatch)
+	ctx, cancel = context.WithCancel(context.Background())
+	cmd = FollowReader(ctx, strings.NewReader(lines))
+	cancel()
+	read := 0
+	for cmd != nil {
+		msg, ok := cmd().(followMsg)
+		if !ok {
+			break
+		}
+		read, cmd = read+len(msg.chunks), msg.next
+	}
+	if read >= 2*maxFollowBatch {
+		t.Errorf("read all %d lines after cancelling", read)
+	}
+}
@@ -0,0 +1,139 @@
+package logview
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// hexBytesPerRow is the number of bytes on each row of a hexdump.
+const hexBytesPerRow = 16
+
+// sniffLength is how much of the log is inspected to guess whether it's
+// binary.
+const sniffLength = 8192
+
+// SetHexMode sets whether lines are displayed as a hexdump, with the
+// offset, hex, and ASCII of every 16 bytes on a row, like `hexdump -C`.
+// Each line is dumped separately, without its line ending. This is useful
+// for binary content, which [Model.LooksBinary] detects.
+func (m *Model) SetHexMode(hex bool) { m.hexMode = hex }
+
+// LooksBinary reports whether the start of the log looks like binary
+// content rather than text, because it contains NUL bytes or a lot of
+// invalid UTF-8.
+func (m *Model) LooksBinary() bool { return m.looksBinary }
+
+// sniff inspects written content, until enough has been seen, to guess
+// whether the log is binary.
+func (m *Model) sniff(content string) {
+	if m.sniffed >= sniffLength {
+		return
+	}
+	content = content[:min(len(content), sniffLength-m.sniffed)]
+	m.sniffed += len(content)
+
+	for len(content) > 0 {
+		r, size := utf8.DecodeRuneInString(content)
+		if r == 0 {
+			m.looksBinary = true
+			return
+		}
+		if r == utf8.RuneError && size == 1 {
+			m.sniffInvalid++
+		}
+		content = content[size:]
+	}
+	// a little invalid UTF-8 is probably just a different encoding
+	m.looksBinary = m.looksBinary || m.sniffInvalid*10 > m.sniffed
+}
+
+// sanitizeLine makes line safe to write to the terminal: invalid UTF-8 and
+// control characters, other than tabs, are replaced with U+FFFD. Escape
+// sequences are only kept if they style text or are hyperlinks; any other
+// sequence, like one clearing the screen, loses its ESC, so it's displayed
+// rather than obeyed.
+func sanitizeLine(line string) string {
+	if isSafe(line) {
+		return line
+	}
+
+	var b strings.Builder
+	for len(line) > 0 {
+		if seq := safeEscapeRe.FindString(line); seq != "" {
+			b.WriteString(seq)
+			line = line[len(seq):]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		if isUnsafeControl(r) {
+			r = utf8.RuneError
+		}
+		b.WriteRune(r)
+		line = line[size:]
+	}
+	return b.String()
+}
+
+// safeEscapeRe matches an escape sequence at the start of a string that
+// sanitizeLine keeps.
+var safeEscapeRe = regexp.MustCompile("^(?:" + sgrRe.String() + "|" + hyperlinkRe.String() + ")")
+
+// isSafe reports whether sanitizeLine would leave line unchanged.
+func isSafe(line string) bool {
+	for len(line) > 0 {
+		if line[0] == '\x1b' {
+			seq := safeEscapeRe.FindString(line)
+			if seq == "" {
+				return false
+			}
+			line = line[len(seq):]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		if (r == utf8.RuneError && size == 1) || isUnsafeControl(r) {
+			return false
+		}
+		line = line[size:]
+	}
+	return true
+}
+
+// isUnsafeControl reports whether r is a control character that could move
+// the terminal's cursor or otherwise corrupt the display. That includes the
+// C1 controls, which some terminals obey even when encoded as UTF-8.
+func isUnsafeControl(r rune) bool {
+	return (r < 0x20 && r != '\t') || (0x7f <= r && r <= 0x9f)
+}
+
+// hexdump renders line like `hexdump -C`, one row per 16 bytes.
+func hexdump(line string) string {
+	if line == "" {
+		return fmt.Sprintf("%08x", 0)
+	}
+
+	var rows []string
+	for offset := 0; offset < len(line); offset += hexBytesPerRow {
+		chunk := line[offset:min(len(line), offset+hexBytesPerRow)]
+
+		var hex, ascii strings.Builder
+		for i := range hexBytesPerRow {
+			if i == hexBytesPerRow/2 {
+				hex.WriteByte(' ')
+			}
+			if i >= len(chunk) {
+				hex.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hex, " %02x", chunk[i])
+			if c := chunk[i]; c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		rows = append(rows, fmt.Sprintf("%08x %s  |%s|", offset, hex.String(), ascii.String()))
+	}
+	return strings.Join(rows, "\n")
+}
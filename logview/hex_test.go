@@ -0,0 +1,79 @@
+package logview
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.WriteBytes([]byte("ELF\x00\x01\x02\xff\xfe\x1b[2J\r\x08ok\n"))
+	if !m.LooksBinary() {
+		t.Error("LooksBinary() = false for content with NUL bytes")
+	}
+
+	// nothing that could move the cursor or clear the screen gets through
+	out := m.RenderLog(40, 1)
+	for _, c := range []string{"\x00", "\x08", "\r", "\x1b[2J", "\xff"} {
+		if strings.Contains(out, c) {
+			t.Errorf("RenderLog() = %q, contains %q", out, c)
+		}
+	}
+
+	m.SetHexMode(true)
+	assertScreen(t, m, 80, 2,
+		"00000000  45 4c 46 00 01 02 ff fe  1b 5b 32 4a 0d 08 6f 6b  |ELF......[2J..ok|",
+		"",
+	)
+}
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"plain text\n", false},
+		{"caf\xe9 latin-1 is mostly valid\n", false},
+		{"\xff\xfe\xfd\xfc\n", true},
+		{"a\x00b\n", true},
+	}
+	for _, tt := range tests {
+		m := New()
+		m.Write(tt.content)
+		if got := m.LooksBinary(); got != tt.want {
+			t.Errorf("LooksBinary() after writing %q = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{"", "00000000"},
+		{"hi", "00000000  68 69                                             |hi|"},
+		{strings.Repeat("a", 17), "00000000  61 61 61 61 61 61 61 61  61 61 61 61 61 61 61 61  |aaaaaaaaaaaaaaaa|\n" +
+			"00000010  61                                                |a|"},
+	}
+	for _, tt := range tests {
+		if got := hexdump(tt.line); got != tt.want {
+			t.Errorf("hexdump(%q) =\n%q\nwant\n%q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeLine(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{"plain\ttext", "plain\ttext"},
+		{"\x1b[1;31mred\x1b[0m", "\x1b[1;31mred\x1b[0m"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"},
+		{"\x1b[2Jcleared", "�[2Jcleared"},
+		{"\x1b]0;title\x07", "�]0;title�"},
+		{"a\rb\x08c\x7f", "a�b�c�"},
+		{"\u009b2J", "�2J"},
+		{"bad \xff utf-8", "bad � utf-8"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeLine(tt.line); got != tt.want {
+			t.Errorf("sanitizeLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
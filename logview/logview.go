@@ -3,34 +3,108 @@ package logview
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"cmp"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/reflow/truncate"
-	"github.com/muesli/reflow/wrap"
+	"github.com/muesli/reflow/wordwrap"
 )
 
 type Styles struct {
-	Log       lipgloss.Style
-	Statusbar lipgloss.Style
+	Log          lipgloss.Style
+	Statusbar    lipgloss.Style
+	CurrentLine  lipgloss.Style
+	LineNumber   lipgloss.Style
+	MinimapMatch lipgloss.Style
+	Invisibles   lipgloss.Style
+	Folded       lipgloss.Style
+	MatchGutter  lipgloss.Style
+	Prompt       lipgloss.Style
+	Dimmed       lipgloss.Style
+
+	// ContextSeparator styles the line between groups of lines shown with
+	// [Model.SetFilterContext].
+	ContextSeparator lipgloss.Style
+
+	// SearchPreview styles the query or find being typed into the search
+	// bar, and the preview of how many lines it matches, until it's
+	// applied.
+	SearchPreview lipgloss.Style
+
+	// Empty styles the placeholder shown when there are no lines, or none
+	// pass the filter.
+	Empty lipgloss.Style
+
+	// Diff styles the words that changed from the line before, with
+	// [Model.SetDiffHighlight].
+	Diff lipgloss.Style
+
+	// Ruler styles the row of column positions shown with
+	// [Model.SetRuler].
+	Ruler lipgloss.Style
+
+	// Stdout and Stderr style lines by the stream they were written to,
+	// once anything has been written to a stream other than stdout.
+	Stdout lipgloss.Style
+	Stderr lipgloss.Style
 }
 
 var defaultStyles = &Styles{
-	Log:       lipgloss.NewStyle(),
-	Statusbar: lipgloss.NewStyle(),
+	Log:          lipgloss.NewStyle(),
+	Statusbar:    lipgloss.NewStyle(),
+	CurrentLine:  lipgloss.NewStyle().Reverse(true),
+	LineNumber:   lipgloss.NewStyle().Faint(true),
+	MinimapMatch: highlight,
+	Invisibles:   lipgloss.NewStyle().Faint(true),
+	Folded:       lipgloss.NewStyle().Faint(true),
+	MatchGutter:  highlight,
+	Prompt:       lipgloss.NewStyle(),
+	Dimmed:       lipgloss.NewStyle().Faint(true),
+	Stdout:       lipgloss.NewStyle(),
+	Stderr:       lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+
+	ContextSeparator: lipgloss.NewStyle().Faint(true),
+	SearchPreview:    lipgloss.NewStyle().Italic(true),
+	Empty:            lipgloss.NewStyle().Faint(true),
+	Diff:             lipgloss.NewStyle().Bold(true),
+	Ruler:            lipgloss.NewStyle().Faint(true),
 }
 
 func (m *Model) View() string {
-	return m.Render(defaultStyles, m.windowWidth, m.windowHeight)
+	width, height := m.viewSize()
+	return m.Render(defaultStyles, width, height)
 }
 
 func (m *Model) Render(styles *Styles, width, height int) string {
+	if m.noColor {
+		return stripColors(m.render(styles, width, height))
+	}
+	return m.render(styles, width, height)
+}
+
+// RenderAt renders the model at the given size as plain text, without any
+// styling or escape sequences, whatever the terminal supports. It's for
+// capturing what the screen looks like headlessly, like in golden tests.
+func (m *Model) RenderAt(width, height int) string {
+	screen := m.render(defaultStyles, width, height)
+	return escapeRe.ReplaceAllString(hyperlinkRe.ReplaceAllString(screen, ""), "")
+}
+
+func (m *Model) render(styles *Styles, width, height int) string {
 	// don't crash if window has zero area
 	if width <= 0 || height <= 0 {
 		return ""
@@ -38,19 +112,19 @@ func (m *Model) Render(styles *Styles, width, height int) string {
 
 	// skip statusbar if window is too short
 	if height < 2 || !m.shouldShowStatusbar {
-		content := m.RenderLog(width, height)
-		logStyle := styles.Log.Copy().
-			Width(width).Height(height).
-			MaxWidth(width).MaxHeight(height)
-		return logStyle.Render(content)
+		return m.renderFramed(styles, width, height)
 	}
 
 	// render logview and statusbar
-	content := m.RenderLog(width, height-1)
-	logStyle := styles.Log.Copy().
-		Width(width).Height(height - 1).
-		MaxWidth(width).MaxHeight(height - 1)
-	logview := logStyle.Render(content)
+	m.input.PromptStyle = styles.Prompt
+	m.findInput.PromptStyle = styles.Prompt
+	// what's being typed hasn't been applied yet, so it's shown as a
+	// preview
+	m.input.TextStyle, m.findInput.TextStyle = lipgloss.NewStyle(), lipgloss.NewStyle()
+	if m.focus == FocusSearchBar {
+		m.bar().TextStyle = styles.SearchPreview
+	}
+	logview := m.renderFramed(styles, width, height-1)
 	statusbar := styles.Statusbar.Copy().
 		Width(width).Height(1).
 		MaxWidth(width).MaxHeight(1).
@@ -60,48 +134,237 @@ func (m *Model) Render(styles *Styles, width, height int) string {
 
 func (m *Model) viewStatusbar() string {
 	result := m.RenderLineStatus()
-	if result != "" {
-		result += "\t"
+	if m.atEOF {
+		result = strings.TrimPrefix(result+" [EOF]", " ")
+	}
+	if m.DiskErr() != nil {
+		result = strings.TrimPrefix(result+" [disk error]", " ")
+	}
+	if m.looksBinary && !m.hexMode {
+		// suggest the hexdump
+		result = strings.TrimPrefix(result+" [binary: x for hex]", " ")
+	}
+	if search := m.RenderSearchStatus(); result != "" && search != "" {
+		result += m.statusSeparator + search
+	} else {
+		result += search
 	}
-	result += m.RenderSearchStatus()
 	return result
 }
 
 func (m *Model) RenderLineStatus() string {
-	lineView := m.lines
-	if m.queryRe != nil {
-		lineView = m.filtered
-	}
-	linecount := len(lineView)
-	if m.buffer != "" {
+	linecount := m.viewLen()
+	if m.showBuffer() && !m.noCountPartial {
 		linecount += 1
 	}
 
+	if m.scrollPosition < 0 && m.rate != nil {
+		return fmt.Sprintf("%.1f lines/s", m.LineRate())
+	}
 	if m.scrollPosition < 0 {
 		return ""
 	}
-	return fmt.Sprintf("%d of %d", m.scrollPosition+1, linecount)
+	return fmt.Sprintf("%d of %d", min(m.scrollPosition+1, linecount), linecount)
 }
 
 func (m *Model) RenderSearchStatus() string {
 	var out string
-	if m.Query() != "" || m.focus == FocusSearchBar {
-		out += m.input.View()
+	editing := m.focus == FocusSearchBar
+	if m.Query() != "" || (editing && !m.editingFind) {
+		if editing && !m.editingFind {
+			out += m.input.View()
+			// while typing, preview how many lines the pattern matches. If
+			// the pattern is momentarily invalid, this is the last valid
+			// preview.
+			if m.filtering() {
+				out += " " + m.input.TextStyle.Render(fmt.Sprintf("~%d", len(m.filtered)))
+			}
+		} else {
+			out += appliedPattern(m.input)
+			if m.filtering() && m.searchScope == SearchScopeAll {
+				out += fmt.Sprintf(" (%d)", len(m.filtered))
+			}
+		}
+		if m.anchorStart {
+			out += " [^]"
+		}
+		if m.anchorEnd {
+			out += " [$]"
+		}
+		if m.searchScope == SearchScopeViewport {
+			out += " [viewport]"
+		}
+	}
+	if m.Find() != "" || (editing && m.editingFind) {
+		if out != "" {
+			out += " "
+		}
+		if editing && m.editingFind {
+			out += m.findInput.View()
+		} else {
+			out += appliedPattern(m.findInput)
+		}
+	}
+	if out != "" {
+		out += m.renderOccurrences()
+		out += m.renderMatchStatus()
+		if m.pendingRe != nil {
+			out += " searching…"
+		} else if m.searchTimedOut {
+			out += " [timed out]"
+		}
 	}
 	return out
 }
 
+// appliedPattern renders the pattern in input after its prompt, without
+// the cursor or padding of an input being typed into.
+func appliedPattern(input *textinput.Model) string {
+	return input.PromptStyle.Render(input.Prompt) + input.TextStyle.Render(input.Value())
+}
+
+// renderFramed renders the log in the Log style at exactly the given size,
+// with the lines fitting within its padding, border, and margins.
+func (m *Model) renderFramed(styles *Styles, width, height int) string {
+	frameWidth, frameHeight := styles.Log.GetFrameSize()
+	content := m.renderLog(styles, max(0, width-frameWidth), max(0, height-frameHeight))
+	// the Log style's width and height include its padding, but not its
+	// border or margins
+	logStyle := styles.Log.Copy().
+		Width(max(0, width-styles.Log.GetHorizontalBorderSize()-styles.Log.GetHorizontalMargins())).
+		Height(max(0, height-styles.Log.GetVerticalBorderSize()-styles.Log.GetVerticalMargins())).
+		MaxWidth(width).MaxHeight(height)
+	return logStyle.Render(content)
+}
+
 func (m *Model) RenderLog(width, height int) string {
-	// If we're tailing, start assembling output from the -end- of the log,
-	// returning it when we have enough
+	if m.noColor {
+		return stripColors(m.renderLog(defaultStyles, width, height))
+	}
+	return m.renderLog(defaultStyles, width, height)
+}
 
-	lineView := m.lines
-	if m.queryRe != nil {
-		lineView = m.filtered
+func (m *Model) renderLog(styles *Styles, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
 	}
-	if m.scrollPosition < 0 {
+	if m.shouldShowMinimap && width > 1 {
+		content := m.renderContent(styles, width-1, height)
+		return m.withMinimap(styles, content, width-1, height)
+	}
+	return m.renderContent(styles, width, height)
+}
+
+func (m *Model) renderContent(styles *Styles, width, height int) string {
+	if m.shouldShowRuler && height > 1 {
+		// the lines are rendered first, since the ruler counts the columns
+		// they're dedented by
+		lines, dedent := m.renderLines(styles, width, height-1)
+		return m.renderRuler(styles, width, len(dedent)) + "\n" + lines
+	}
+	lines, _ := m.renderLines(styles, width, height)
+	return lines
+}
+
+// rulerTick is the distance between the numbered columns of the ruler.
+const rulerTick = 10
+
+// renderRuler renders a row numbering the columns of the lines below it,
+// starting past the gutter, and counting the offset columns trimmed by
+// dedenting.
+func (m *Model) renderRuler(styles *Styles, width, offset int) string {
+	width = m.contentWidth(width)
+	var b strings.Builder
+	for i := 0; i < width; {
+		if col := offset + i; col%rulerTick == 0 {
+			if label := strconv.Itoa(col); i+len(label) <= width {
+				b.WriteString(label)
+				i += len(label)
+				continue
+			}
+		}
+		b.WriteString("·")
+		i++
+	}
+	return strings.Repeat(" ", m.gutterWidth) + styles.Ruler.Render(b.String())
+}
+
+// renderLines renders the sticky header and the lines below it, returning
+// the leading whitespace they were dedented by.
+func (m *Model) renderLines(styles *Styles, width, height int) (string, string) {
+	m.gutterWidth = m.computeGutterWidth()
+	m.sizeExtractColumns(height)
+	header, headerHeight := m.renderStickyHeader(styles, width, height)
+	if headerHeight >= height {
+		return header, ""
+	}
+	dedent := m.visibleIndent(height - headerHeight)
+	body := m.renderBody(styles, width, height-headerHeight, dedent)
+	if header == "" {
+		return body, dedent
+	}
+	return header + "\n" + body, dedent
+}
+
+// withMinimap adds a column to the right of content marking the rows of the
+// viewport that correspond to regions of the log containing matches.
+func (m *Model) withMinimap(styles *Styles, content string, width, height int) string {
+	marked := make([]bool, height)
+	if total := m.lineCount(); m.filtering() && total > 0 {
+		for _, idx := range m.filtered {
+			if m.reverse {
+				idx = total - 1 - idx
+			}
+			marked[idx*height/total] = true
+		}
+	}
+
+	rows := strings.Split(content, "\n")
+	for len(rows) < height {
+		rows = append(rows, "")
+	}
+	for i, row := range rows[:height] {
+		mark := " "
+		if marked[i] {
+			mark = styles.MinimapMatch.Render("▐")
+		}
+		pad := strings.Repeat(" ", max(0, width-stringWidth(row)))
+		rows[i] = row + pad + mark
+	}
+	return strings.Join(rows[:height], "\n")
+}
+
+// renderStickyHeader renders the first m.stickyHeader lines of the log,
+// which stay pinned to the top of the viewport regardless of scrolling.
+func (m *Model) renderStickyHeader(styles *Styles, width, height int) (string, int) {
+	var (
+		output       []string
+		outputHeight = 0
+	)
+	for i := 0; i < min(m.stickyHeader, m.lineCount()) && outputHeight < height; i++ {
+		wrapped, wrappedHeight := m.wrapLine(m.displayLine(styles, i, ""), height-outputHeight, m.contentWidth(width))
+		output = append(output, m.withGutter(styles, wrapped, "", m.timeLabel(i), false))
+		outputHeight += wrappedHeight
+	}
+	return strings.Join(output, "\n"), outputHeight
+}
+
+// renderBody renders the lines in the view that fit in the viewport, with
+// the leading whitespace dedent trimmed from them.
+func (m *Model) renderBody(styles *Styles, width, height int, dedent string) string {
+	m.bodyWidth, m.bodyHeight = width, height
+	if placeholder := m.emptyPlaceholder(); placeholder != "" {
+		placeholder = styles.Empty.Render(truncateWidth(placeholder, width))
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, placeholder)
+	}
+
+	// If we're tailing, start assembling output from the -end- of the log,
+	// returning it when we have enough. In reverse, the end of the log is at
+	// the top, so tailing is handled like scrolling to the top below.
+
+	if m.scrollPosition < 0 && !m.reverse {
 		var (
-			linecount    = len(lineView)
+			linecount    = m.viewLen()
 			pointer      = linecount - 1
 			output       = ""
 			outputHeight = 0
@@ -109,19 +372,21 @@ func (m *Model) RenderLog(width, height int) string {
 		)
 
 		// handle the buffer, if present
-		if m.buffer != "" {
-			wrapped, wrappedHeight := m.wrapLine(m.buffer, targetHeight, width)
+		if m.showBuffer() {
+			wrapped, wrappedHeight := m.renderBuffer(styles, targetHeight, width)
 			output = "\n" + wrapped
 			outputHeight = wrappedHeight
 		}
 
 		for ; outputHeight < targetHeight && pointer >= 0; pointer-- {
-			l := lineView[pointer]
-			wrapped, wrappedHeight := m.wrapLine(l, targetHeight-outputHeight, width)
+			wrapped, wrappedHeight := m.renderLine(styles, pointer, 0, targetHeight-outputHeight, width, dedent)
 			output = "\n" + wrapped + output
 			outputHeight += wrappedHeight
 		}
-		m.firstDisplayedLine = pointer
+		// if the buffer fills the viewport by itself, no complete line is
+		// displayed, so the last one counts as the first for scrolling
+		m.firstDisplayedLine = max(0, min(pointer+1, linecount-1))
+		m.lastDisplayedLine = linecount - 1
 
 		output = strings.TrimPrefix(output, "\n")
 
@@ -137,53 +402,385 @@ func (m *Model) RenderLog(width, height int) string {
 	}
 
 	// If we're not tailing, start from m.scrollPosition and keep adding
-	// wrapped output until we reach m.logHeight
+	// wrapped output until we fill the viewport
 	var (
-		linecount    = len(lineView)
-		pointer      = m.scrollPosition
+		linecount    = m.viewLen()
+		pointer      = max(0, m.scrollPosition)
 		output       = ""
 		outputHeight = 0
-		targetHeight = m.logHeight(height)
+		targetHeight = height
 	)
 
-	m.firstDisplayedLine = m.scrollPosition
+	m.firstDisplayedLine = pointer
+	m.restoreTopRow(m.contentWidth(width), dedent)
+
+	// in reverse, the buffer is the newest line, so it goes first
+	if m.reverse && pointer == 0 && m.showBuffer() {
+		wrapped, wrappedHeight := m.renderBuffer(styles, targetHeight, width)
+		output = wrapped + "\n"
+		outputHeight = wrappedHeight
+	}
 
 	// handle the lines
 	for ; outputHeight < targetHeight && pointer < linecount; pointer++ {
-		l := lineView[pointer]
-		wrapped, wrappedHeight := m.wrapLine(l, targetHeight-outputHeight, width)
+		skip := 0
+		if pointer == m.scrollPosition {
+			skip = m.topRowOffset()
+		}
+		wrapped, wrappedHeight := m.renderLine(styles, pointer, skip, targetHeight-outputHeight, width, dedent)
 		output = output + wrapped + "\n"
 		outputHeight += wrappedHeight
 	}
+	m.lastDisplayedLine = pointer - 1
 
 	// handle the buffer
-	if outputHeight < targetHeight && m.buffer != "" {
-		l := m.buffer
-		wrapped, wrappedHeight := m.wrapLine(l, targetHeight-outputHeight, width)
+	if !m.reverse && outputHeight < targetHeight && m.showBuffer() {
+		wrapped, wrappedHeight := m.renderBuffer(styles, targetHeight-outputHeight, width)
 		output = output + wrapped + "\n"
 		outputHeight += wrappedHeight
 	}
 
+	// a partial page is at the top unless it's aligned to the bottom
+	if m.pageAlignment == PageAlignBottom && outputHeight < targetHeight {
+		output = strings.Repeat(strings.Repeat(" ", width)+"\n", targetHeight-outputHeight) + output
+	}
+
 	return strings.TrimSuffix(output, "\n")
 }
 
+// emptyPlaceholder returns what's shown in place of the lines when there
+// are none to show, or "" if there are.
+func (m *Model) emptyPlaceholder() string {
+	switch {
+	case m.viewLen() > 0 || m.showBuffer():
+		return ""
+	case m.lineCount() <= m.stickyHeader:
+		// there's nothing below the header
+		return "(empty)"
+	case m.queryRe != nil && m.searchScope == SearchScopeAll:
+		return fmt.Sprintf("No matches for /%s/", m.Query())
+	default:
+		return "No matches"
+	}
+}
+
+// SetPartialPageAlignment sets where lines are shown in the viewport when
+// there aren't enough to fill it, like at the end of the log when it's not
+// being tailed. The default is [PageAlignTop]. While tailing, lines are
+// always at the bottom.
+func (m *Model) SetPartialPageAlignment(alignment PageAlignment) { m.pageAlignment = alignment }
+
+// renderLine renders the line at position pos in the current view, wrapped
+// to fit within maxLines rows of width, and decorated with any gutter. The
+// first skip rows of the wrapped line are left out, and so is the leading
+// whitespace dedent.
+func (m *Model) renderLine(styles *Styles, pos, skip, maxLines, width int, dedent string) (string, int) {
+	var (
+		line    string
+		stamp   string
+		matched bool
+	)
+	if f, ok := m.foldAt(m.viewIndex(pos)); ok {
+		line = m.foldSummary(styles, f)
+	} else {
+		line = m.displayLine(styles, m.viewIndex(pos), dedent)
+		matched = m.shouldShowMatchGutter && m.highlightRe() != nil && m.isMatch(m.viewIndex(pos))
+		stamp = m.timeLabel(m.viewIndex(pos))
+	}
+	// separate groups of lines shown with context, if there's room
+	separate := skip == 0 && maxLines > 1 && m.startsGroup(pos)
+	if separate {
+		maxLines--
+	}
+
+	wrapped, wrappedHeight := m.wrapLine(line, maxLines+skip, m.contentWidth(width))
+	if skip > 0 && skip < wrappedHeight {
+		rows := strings.Split(wrapped, "\n")
+		wrapped, wrappedHeight = strings.Join(rows[skip:], "\n"), wrappedHeight-skip
+	}
+	if pos == m.cursor && m.scrollPosition >= 0 {
+		wrapped = styles.CurrentLine.Render(wrapped)
+	}
+	wrapped = m.withGutter(styles, wrapped, m.lineLabel(pos), stamp, matched)
+	if separate {
+		return styles.ContextSeparator.Render(contextSeparator) + "\n" + wrapped, wrappedHeight + 1
+	}
+	return wrapped, wrappedHeight
+}
+
+// renderBuffer renders the incomplete last line, like renderLine.
+func (m *Model) renderBuffer(styles *Styles, maxLines, width int) (string, int) {
+	// a trailing "\r" is probably half of a CRLF; don't render it
+	buffer := strings.TrimSuffix(m.buffer, "\r")
+	if m.hexMode {
+		buffer = hexdump(buffer)
+	} else {
+		buffer = m.styleStream(styles, m.bufferStream, sanitizeLine(buffer))
+	}
+	wrapped, wrappedHeight := m.wrapLine(buffer, maxLines, m.contentWidth(width))
+	return m.withGutter(styles, wrapped, "", "", false), wrappedHeight
+}
+
+// contentWidth is the width left for line content once the gutter is drawn.
+func (m *Model) contentWidth(width int) int {
+	return max(1, width-m.gutterWidth)
+}
+
+// withGutter prefixes the first row of a wrapped line with label and the
+// line's timestamp, stamp, and its continuation rows with blank space, so
+// that content stays aligned.
+func (m *Model) withGutter(styles *Styles, wrapped, label, stamp string, matched bool) string {
+	if m.gutterWidth == 0 {
+		return wrapped
+	}
+	numberWidth := m.gutterWidth
+	if m.shouldShowMatchGutter {
+		numberWidth -= matchGutterWidth
+	}
+	if m.timeDisplay != TimeHidden {
+		numberWidth -= timeGutterWidth
+	}
+
+	rows := strings.Split(wrapped, "\n")
+	for i := range rows {
+		var gutter string
+		if m.shouldShowMatchGutter {
+			mark := strings.Repeat(" ", matchGutterWidth)
+			if matched && i == 0 {
+				mark = styles.MatchGutter.Render("▸") + " "
+			}
+			gutter += mark
+		}
+		if m.timeDisplay != TimeHidden {
+			if i > 0 {
+				stamp = ""
+			}
+			gutter += styles.LineNumber.Render(fmt.Sprintf("%-*s", timeGutterWidth, stamp))
+		}
+		if numberWidth > 0 {
+			number := fmt.Sprintf("%*s ", numberWidth-1, label)
+			if i > 0 {
+				number = strings.Repeat(" ", numberWidth)
+			}
+			gutter += styles.LineNumber.Render(number)
+		}
+		rows[i] = gutter + rows[i]
+	}
+	return strings.Join(rows, "\n")
+}
+
+// matchGutterWidth is the width of the column marking matching lines.
+const matchGutterWidth = 2
+
+// computeGutterWidth sizes the gutter to fit the largest number it could
+// display, plus a space to separate it from the content, and the columns
+// marking matching lines and showing timestamps.
+func (m *Model) computeGutterWidth() int {
+	var width int
+	if m.shouldShowMatchGutter {
+		width += matchGutterWidth
+	}
+	if m.timeDisplay != TimeHidden {
+		width += timeGutterWidth
+	}
+	if m.relativeLineNumbers {
+		largest := max(m.viewLen(), m.CurrentLine()+1)
+		width += len(fmt.Sprint(largest)) + 1
+	}
+	return width
+}
+
+// lineLabel returns the gutter label for the line at position pos in the
+// current view. With relative line numbers, the current line shows its
+// absolute line number and the rest show their distance from it.
+func (m *Model) lineLabel(pos int) string {
+	if !m.relativeLineNumbers {
+		return ""
+	}
+	current := m.cursorPosition()
+	if pos == current {
+		return fmt.Sprint(m.viewIndex(pos) + 1)
+	}
+	distance := pos - current
+	return fmt.Sprint(max(distance, -distance))
+}
+
+// cursorPosition returns the position of the current line within the
+// current view. While tailing, that's the newest line.
+func (m *Model) cursorPosition() int {
+	if m.scrollPosition >= 0 {
+		return m.cursor
+	}
+	if m.reverse {
+		return 0
+	}
+	return m.viewLen() - 1
+}
+
 func (m *Model) wrapLine(line string, maxLines, width int) (string, int) {
+	wrapped, wrappedHeight := m.cachedWrap(line, width)
+	if wrappedHeight > maxLines {
+		wrappedHeight = maxLines
+		if m.scrollPosition < 0 && !m.reverse {
+			wrapped = lastNLines(wrapped, wrappedHeight)
+		} else {
+			wrapped = firstNLines(wrapped, wrappedHeight)
+		}
+	}
+	return wrapped, wrappedHeight
+}
+
+// maxWrapCacheSize bounds the number of lines kept in the wrap cache. When
+// it fills up, it's cleared and starts over.
+const maxWrapCacheSize = 4096
+
+type wrapResult struct {
+	wrapped string
+	height  int
+}
+
+// cachedWrap returns the wrapped form of line and its height in rows,
+// reusing the result from a previous render if possible. The cache is
+// invalidated whenever the width, wrap mode, wrap style, or hanging indent
+// changes.
+func (m *Model) cachedWrap(line string, width int) (string, int) {
+	if m.wrapCache == nil || len(m.wrapCache) >= maxWrapCacheSize ||
+		width != m.wrapCacheWidth || m.shouldHardwrap != m.wrapCacheHardwrap ||
+		m.wrapStyle != m.wrapCacheStyle || m.hangingIndent != m.wrapCacheIndent {
+		m.wrapCache = make(map[string]wrapResult)
+		m.wrapCacheWidth, m.wrapCacheHardwrap = width, m.shouldHardwrap
+		m.wrapCacheStyle, m.wrapCacheIndent = m.wrapStyle, m.hangingIndent
+	}
+	if result, ok := m.wrapCache[line]; ok {
+		return result.wrapped, result.height
+	}
+	wrapped := m.wrap(line, width)
+	result := wrapResult{wrapped, strings.Count(wrapped, "\n") + 1}
+	m.wrapCache[line] = result
+	return result.wrapped, result.height
+}
+
+func (m *Model) wrap(line string, width int) string {
+	// wrapping doesn't understand OSC 8 hyperlinks, so hide them from it
+	if strings.Contains(line, "\x1b]8;") {
+		line, links := hideHyperlinks(line)
+		return restoreHyperlinks(m.wrap(line, width), links)
+	}
+
+	// lines already made up of several rows, like hexdumps, have each row
+	// wrapped separately
+	if strings.Contains(line, "\n") {
+		rows := strings.Split(line, "\n")
+		for i, row := range rows {
+			rows[i] = m.wrap(row, width)
+		}
+		return strings.Join(rows, "\n")
+	}
+
 	if m.shouldHardwrap {
-		wrapped := truncate.String(line, uint(width))
-		return wrapped, 1
-	} else {
-		wrapped := wrap.String(line, width)
-		wrappedHeight := strings.Count(wrapped, "\n") + 1
-		if wrappedHeight > maxLines {
-			wrappedHeight = maxLines
-			if m.scrollPosition < 0 {
-				wrapped = lastNLines(wrapped, wrappedHeight)
+		return carrySGR(truncateWidth(line, width))
+	}
+	if indent := leadingSpaceRe.FindString(line); m.hangingIndent && indent != "" {
+		// Wrap what follows the indent to the width left beside it, and
+		// indent every row. If that would leave too little room, wrap
+		// as usual instead.
+		indentWidth := stringWidth(indent)
+		if room := width - indentWidth; room >= width/2 && room > 0 {
+			rows := m.wrap(line[len(indent):], room)
+			return indent + strings.ReplaceAll(rows, "\n", "\n"+indent)
+		}
+	}
+	if m.wrapStyle == WrapWords {
+		// break at word boundaries where possible, and force a break
+		// within any word that's still too long
+		return carrySGR(hardwrap(wordwrap.String(line, width), width))
+	}
+	return carrySGR(hardwrap(line, width))
+}
+
+var leadingSpaceRe = regexp.MustCompile(`^[ \t]+`)
+
+var sgrRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// carrySGR makes styles that span a soft-wrap boundary, like a highlighted
+// match, continue onto the next row. Each row that ends with a style active
+// is reset, and the style is re-emitted at the start of the following row.
+// Even a line that isn't wrapped is reset if it leaves a style active, so
+// that it can't leak into whatever is rendered after it.
+func carrySGR(wrapped string) string {
+	if !strings.Contains(wrapped, "\x1b[") {
+		return wrapped
+	}
+	rows := strings.Split(wrapped, "\n")
+	active := ""
+	for i, row := range rows {
+		carried := active
+		for _, seq := range sgrRe.FindAllString(row, -1) {
+			if seq == "\x1b[0m" || seq == "\x1b[m" {
+				active = ""
 			} else {
-				wrapped = firstNLines(wrapped, wrappedHeight)
+				active += seq
+			}
+		}
+		if active != "" {
+			row += "\x1b[0m"
+		}
+		rows[i] = carried + row
+	}
+	return strings.Join(rows, "\n")
+}
+
+var (
+	hyperlinkRe   = regexp.MustCompile("\x1b\\]8;[^;\x07\x1b]*;[^\x07\x1b]*(?:\x1b\\\\|\x07)")
+	placeholderRe = regexp.MustCompile("\x1b\\[8;[0-9]+z")
+)
+
+const closeHyperlink = "\x1b]8;;\x1b\\"
+
+// hideHyperlinks replaces each OSC 8 hyperlink sequence in line with a
+// placeholder CSI sequence, which wrapping treats as zero-width. It returns
+// the sequences that were replaced, in order.
+func hideHyperlinks(line string) (string, []string) {
+	var links []string
+	line = hyperlinkRe.ReplaceAllStringFunc(line, func(link string) string {
+		links = append(links, link)
+		return fmt.Sprintf("\x1b[8;%dz", len(links)-1)
+	})
+	return line, links
+}
+
+// restoreHyperlinks puts back the hyperlinks hidden by hideHyperlinks. Links
+// left open at the end of a row are closed there and reopened on the next
+// row, so that every row stays clickable and none leak past the log.
+func restoreHyperlinks(wrapped string, links []string) string {
+	rows := strings.Split(wrapped, "\n")
+	open := ""
+	for i, row := range rows {
+		reopen := open
+		row = reopen + placeholderRe.ReplaceAllStringFunc(row, func(placeholder string) string {
+			var n int
+			fmt.Sscanf(placeholder, "\x1b[8;%dz", &n)
+			if n < 0 || n >= len(links) {
+				return ""
 			}
+			if open = links[n]; isHyperlinkClose(open) {
+				open = ""
+			}
+			return links[n]
+		})
+		if open != "" {
+			row += closeHyperlink
 		}
-		return wrapped, wrappedHeight
+		rows[i] = row
 	}
+	return strings.Join(rows, "\n")
+}
+
+// isHyperlinkClose reports whether an OSC 8 sequence ends a hyperlink,
+// which is signified by an empty URI.
+func isHyperlinkClose(link string) bool {
+	link = strings.TrimSuffix(strings.TrimSuffix(link, "\x1b\\"), "\x07")
+	return strings.HasSuffix(link, ";")
 }
 
 func firstNLines(s string, n int) string {
@@ -210,53 +807,266 @@ func clamp[T cmp.Ordered](lower, upper, val T) T {
 	return max(lower, min(upper, val))
 }
 
-func (m *Model) search() []string {
-	if m.queryRe == nil {
+func (m *Model) search() []int {
+	if !m.filtering() {
+		// let go of the old results entirely
 		return nil
 	}
+	// the old results are being replaced, so their memory can be reused
+	results, _ := m.searchFunc(m.queryRe)(context.Background(), m.snapshot(), m.filtered[:0])
+	return results
+}
+
+// matchLine reports whether the line at lineno passes the current filter.
+func (m *Model) matchLine(lineno int) bool {
+	if lineno < 0 || lineno >= m.lineCount() {
+		return false
+	}
+	return m.lineMatcher(m.queryRe)(m.line(lineno), m.snapshot().stream(lineno))
+}
 
-	var results []string
-	for i := range m.lines {
-		if thing := m.searchLine(i); thing != nil {
-			results = append(results, *thing)
+// lineMatcher returns a function reporting whether a line matches queryRe
+// and is from one of the filtered streams. It doesn't refer back to the
+// model, so it's safe to call from another goroutine.
+func (m *Model) lineMatcher(queryRe *regexp.Regexp) func(line string, stream Stream) bool {
+	if m.searchScope != SearchScopeAll {
+		queryRe = nil
+	}
+	streams, strip := m.streamFilter, m.matchStripANSI
+	include, exclude := m.filterInclude, m.filterExclude
+	field := m.fieldMatcher()
+	var extractRe *regexp.Regexp
+	if m.hideUnextracted {
+		extractRe = m.extractRe
+	}
+	return func(line string, stream Stream) bool {
+		if streams != nil && !slices.Contains(streams, stream) {
+			return false
+		}
+		if extractRe != nil && !extractRe.MatchString(line) {
+			return false
+		}
+		if strip {
+			line = stripEscapes(line)
 		}
+		if include != nil && !include.MatchString(line) {
+			return false
+		}
+		if exclude != nil && exclude.MatchString(line) {
+			return false
+		}
+		if field != nil && !field(line) {
+			return false
+		}
+		return queryRe == nil || queryRe.MatchString(line)
 	}
-	return results
 }
 
-func (m *Model) searchLine(lineno int) *string {
-	if m.queryRe == nil {
-		return nil
+// displayLine returns the line at lineno as it should be rendered, with the
+// leading whitespace dedent trimmed and any search matches highlighted.
+func (m *Model) displayLine(styles *Styles, lineno int, dedent string) string {
+	if m.hexMode {
+		return hexdump(m.line(lineno))
+	}
+	line := m.transformLine(styles, strings.TrimPrefix(m.line(lineno), dedent))
+	if m.diffHighlight {
+		if prev := m.prevInView(lineno); prev >= 0 {
+			line = highlightDiff(styles.Diff, line, m.transformLine(styles, strings.TrimPrefix(m.line(prev), dedent)))
+		}
+	}
+	line = m.styleStream(styles, m.snapshot().stream(lineno), line)
+	if m.highlightRe() != nil {
+		if m.isContext(lineno) && m.findRe == nil {
+			return line
+		}
+		if m.dimNonMatches && !m.isMatch(lineno) {
+			return styles.Dimmed.TabWidth(lipgloss.NoTabConversion).Render(line)
+		}
+		return m.cachedHighlight(line)
 	}
+	return line
+}
 
-	var line string
-	if lineno < 0 {
-		return nil
+// SetDedent sets whether the leading whitespace common to the lines on
+// screen is trimmed from them, to make room for deeply indented logs. It's
+// worked out again as the viewport scrolls. Blank lines don't count.
+func (m *Model) SetDedent(dedent bool) { m.dedent = dedent }
+
+// visibleIndent returns the leading whitespace common to the lines that
+// can fit in a viewport height rows tall at the current scroll position,
+// ignoring blank ones, if dedenting.
+func (m *Model) visibleIndent(height int) string {
+	first := max(0, m.scrollPosition)
+	if m.scrollPosition < 0 && !m.reverse {
+		first = max(0, m.viewLen()-height)
+	}
+	return m.indentFrom(first, height)
+}
+
+// indentFrom is like visibleIndent, but for a viewport scrolled to position
+// first.
+func (m *Model) indentFrom(first, height int) string {
+	if !m.dedent {
+		return ""
+	}
+	n := m.viewLen()
+	var prefix string
+	found := false
+	for pos := first; pos < min(n, first+height); pos++ {
+		line := m.line(m.viewIndex(pos))
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := leadingSpaceRe.FindString(line)
+		if !found {
+			prefix, found = indent, true
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+		if prefix == "" {
+			break
+		}
+	}
+	return prefix
+}
+
+// SetDimNonMatches sets whether lines in the view that don't match the
+// query are rendered in the Dimmed style, drawing the eye to the ones that
+// do while keeping their context. It's meant for when the query only
+// highlights, like with [SearchScopeViewport], since otherwise every line
+// in the view matches.
+func (m *Model) SetDimNonMatches(dim bool) { m.dimNonMatches = dim }
+
+// maxHighlightCacheSize bounds the number of lines kept in the highlight
+// cache. When it fills up, it's cleared and starts over.
+const maxHighlightCacheSize = 4096
+
+// cachedHighlight returns line with the query's matches highlighted,
+// reusing the result from a previous render if possible. Only lines that
+// are displayed are ever highlighted. The cache is invalidated whenever the
+// query or the highlight style changes.
+func (m *Model) cachedHighlight(line string) string {
+	if m.highlightCache == nil || len(m.highlightCache) >= maxHighlightCacheSize ||
+		m.highlightRe() != m.highlightCacheRe || m.noColor != m.highlightCacheNoColor {
+		m.highlightCache = make(map[string]string)
+		m.highlightCacheRe, m.highlightCacheNoColor = m.highlightRe(), m.noColor
+	}
+	if highlighted, ok := m.highlightCache[line]; ok {
+		return highlighted
+	}
+	highlighted := line
+	if result := m.searchLine(line); result != nil {
+		highlighted = *result
+	}
+	m.highlightCache[line] = highlighted
+	return highlighted
+}
+
+// transformLine applies render-time transformations, like column
+// selection, to a line. The stored line is left untouched.
+func (m *Model) transformLine(styles *Styles, line string) string {
+	if m.extractRe != nil {
+		line = m.extractColumns(line)
+	} else if m.columnFields != nil {
+		line = m.selectColumns(line)
+	}
+	if m.shouldShowInvisibles {
+		line = showInvisibles(styles, line)
+	}
+	line = sanitizeLine(line)
+	if m.colorizer != nil {
+		line = m.colorizer.Colorize(line)
+	}
+	return line
+}
+
+// styleStream styles line by the stream it was written to. Logs that only
+// ever had stdout are left alone.
+func (m *Model) styleStream(styles *Styles, stream Stream, line string) string {
+	if !m.multipleStreams {
+		return line
+	}
+	style := styles.Stdout
+	if stream == StreamStderr {
+		style = styles.Stderr
+	}
+	return style.TabWidth(lipgloss.NoTabConversion).Render(line)
+}
+
+// showInvisibles replaces trailing spaces, tabs, and control characters in
+// line with visible, single-width stand-ins. Escape characters are left
+// alone, since they're usually the start of a color sequence.
+func showInvisibles(styles *Styles, line string) string {
+	trimmed := strings.TrimRight(line, " ")
+	trailing := len(line) - len(trimmed)
+
+	var b strings.Builder
+	for _, r := range trimmed {
+		switch {
+		case r == '\t':
+			b.WriteString(styles.Invisibles.Render("→"))
+		case r == '\x1b':
+			b.WriteRune(r)
+		case r < 0x20:
+			// control pictures, like ␀, are laid out in the same order
+			b.WriteString(styles.Invisibles.Render(string(0x2400 + r)))
+		case r == 0x7f:
+			b.WriteString(styles.Invisibles.Render("␡"))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if trailing > 0 {
+		b.WriteString(styles.Invisibles.Render(strings.Repeat("·", trailing)))
+	}
+	return b.String()
+}
+
+// selectColumns returns only the fields of line chosen by SetColumns, in
+// the order they were given. Out-of-range fields render empty.
+func (m *Model) selectColumns(line string) string {
+	var (
+		fields []string
+		sep    = m.columnDelimiter
+	)
+	if sep == "" {
+		fields, sep = strings.Fields(line), " "
 	} else {
-		line = m.lines[lineno]
+		fields = strings.Split(line, sep)
+	}
+
+	selected := make([]string, len(m.columnFields))
+	for i, field := range m.columnFields {
+		if field >= 0 && field < len(fields) {
+			selected[i] = fields[field]
+		}
+	}
+	return strings.Join(selected, sep)
+}
+
+func (m *Model) searchLine(line string) *string {
+	if m.highlightRe() == nil {
+		return nil
 	}
+
 	var result *string
 	start := 0
-	for _, m := range m.queryRe.FindAllStringIndex(line, -1) {
-		if m[0] > start {
-			stuff := line[start:m[0]]
+	style := m.highlightStyle()
+	for _, match := range m.findMatches(line) {
+		if match.Start > start {
+			stuff := line[start:match.Start]
 			if result != nil {
 				stuff = *result + stuff
 			}
 			result = &stuff
 		}
-		start = m[1]
-		stuff := highlight.Render(line[m[0]:m[1]])
+		start = match.End
+		stuff := highlightSpan(style, line[match.Start:match.End])
 		if result != nil {
 			stuff = *result + stuff
 		}
 		result = &stuff
-		// TODO: fix me
-		// results = append(results, searchResult{
-		// 	line:   lineno,
-		// 	char:   m[0],
-		// 	length: m[1] - m[0],
-		// })
 	}
 	if result != nil {
 		stuff := *result + line[start:]
@@ -265,171 +1075,824 @@ func (m *Model) searchLine(lineno int) *string {
 	return result
 }
 
-func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		cmd := m.handleKey(msg)
-		return m, cmd
-	case tea.MouseMsg:
-		m.handleMouse(msg)
-	default:
-		newInput, cmd := m.input.Update(msg)
-		m.input = &newInput
-		return m, cmd
+// Match is where the query matches within a line, as byte offsets: the
+// match is line[Start:End].
+type Match struct {
+	Start, End int
+}
+
+// Matches returns where the query matches within the line at lineIndex, in
+// order, or nil if there's no query or the line is out of range. Matches
+// within escape sequences in the line are left out.
+func (m *Model) Matches(lineIndex int) []Match {
+	if m.highlightRe() == nil || lineIndex < 0 || lineIndex >= m.lineCount() {
+		return nil
 	}
-	return m, nil
+	return m.findMatches(m.line(lineIndex))
 }
 
-func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
-	if m.focus == FocusHelp {
-		switch msg.String() {
-		case "esc", "ctrl+c", "q", "h", "?":
-			m.SetFocus(FocusLogPane)
+// findMatches returns where the query, or the find if there is one,
+// matches within line, skipping any matches within escape sequences, like
+// those added by a colorizer, which would be mangled by highlighting.
+func (m *Model) findMatches(line string) []Match {
+	re := m.highlightRe()
+	var escapes [][]int
+	if strings.Contains(line, "\x1b") {
+		escapes = escapeRe.FindAllStringIndex(line, -1)
+	}
+
+	if re == m.fieldRe {
+		return m.fieldMatches(line, escapes)
+	}
+	if m.matchStripANSI && escapes != nil {
+		return visibleMatches(re, line, escapes)
+	}
+
+	var matches []Match
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		if !overlapsAny(loc, escapes) {
+			matches = append(matches, Match{loc[0], loc[1]})
 		}
-		return nil
+	}
+	return matches
+}
+
+// SetMatchStripANSI sets whether the query is matched against lines with
+// their escape sequences removed, so that it matches what's visible in
+// lines that were colored before being written. Highlights and
+// [Model.Matches] still refer to the line as written.
+func (m *Model) SetMatchStripANSI(strip bool) {
+	m.matchStripANSI = strip
+	m.searchNow()
+}
+
+// matchText returns what the query is matched against in line.
+func (m *Model) matchText(line string) string {
+	if m.matchStripANSI {
+		return stripEscapes(line)
+	}
+	return line
+}
+
+// stripEscapes removes the escape sequences from line.
+func stripEscapes(line string) string {
+	if !strings.Contains(line, "\x1b") {
+		return line
+	}
+	return escapeRe.ReplaceAllString(line, "")
+}
+
+// visibleMatches returns where queryRe matches line once the given escape
+// sequences are removed, mapped back to positions in line.
+func visibleMatches(queryRe *regexp.Regexp, line string, escapes [][]int) []Match {
+	// raw[i] is the position in line of the ith visible byte
+	var (
+		visible strings.Builder
+		raw     []int
+		pos     int
+	)
+	for _, esc := range append(escapes, []int{len(line), len(line)}) {
+		for ; pos < esc[0]; pos++ {
+			visible.WriteByte(line[pos])
+			raw = append(raw, pos)
+		}
+		pos = esc[1]
+	}
+	raw = append(raw, len(line))
+
+	var matches []Match
+	for _, loc := range queryRe.FindAllStringIndex(visible.String(), -1) {
+		match := Match{raw[loc[0]], raw[loc[0]]}
+		if loc[1] > loc[0] {
+			match.End = raw[loc[1]-1] + 1
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// highlightSpan renders span in style, leaving any escape sequences within
+// it alone.
+func highlightSpan(style lipgloss.Style, span string) string {
+	if !strings.Contains(span, "\x1b") {
+		return style.Render(span)
+	}
+	var b strings.Builder
+	start := 0
+	for _, esc := range escapeRe.FindAllStringIndex(span, -1) {
+		if esc[0] > start {
+			b.WriteString(style.Render(span[start:esc[0]]))
+		}
+		b.WriteString(span[esc[0]:esc[1]])
+		start = esc[1]
+	}
+	if start < len(span) {
+		b.WriteString(style.Render(span[start:]))
+	}
+	return b.String()
+}
+
+var escapeRe = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// overlapsAny reports whether the span [start, end) overlaps any of spans.
+func overlapsAny(span []int, spans [][]int) bool {
+	for _, other := range spans {
+		if span[0] < other[1] && other[0] < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.blurred {
+			return m, nil
+		}
+		cmd := m.handleKey(msg)
+		return m, cmd
+	case tea.MouseMsg:
+		return m, m.handleMouse(msg)
+	case tea.WindowSizeMsg:
+		m.SetDimensions(msg.Width, msg.Height)
+		return m, nil
+	case WriteMsg:
+		m.Write(string(msg))
+		return m, nil
+	case WriteBatchMsg:
+		m.writeBatch(msg)
+		return m, nil
+	case filterDoneMsg:
+		m.handleFilterDone(msg)
+		return m, nil
+	case followMsg:
+		return m, m.handleFollow(msg)
+	case tickMsg:
+		return m, m.handleTick(msg)
+	case heldKeyTimeoutMsg:
+		m.keys.handleHeldKeyTimeout(msg)
+		return m, nil
+	default:
+		input := m.bar()
+		newInput, cmd := input.Update(msg)
+		*input = newInput
+		return m, cmd
+	}
+}
+
+// pastedQuery prepares text pasted into the search bar. Line breaks are
+// escaped, rather than flattened into spaces by the input, so that the
+// query still means what was pasted: with [Model.SetMultilineMatch] it
+// matches across lines, and otherwise it matches nothing. Line breaks at
+// the end, as when a whole line is copied, are dropped.
+func pastedQuery(runes []rune) []rune {
+	text := strings.TrimRight(strings.ReplaceAll(string(runes), "\r\n", "\n"), "\r\n")
+	return []rune(strings.NewReplacer("\n", `\n`, "\r", `\r`).Replace(text))
+}
+
+func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	if m.focus == FocusHelp {
+		switch msg.String() {
+		case "esc", "ctrl+c", "q", "h", "?":
+			m.SetFocus(FocusLogPane)
+		}
+		return nil
 	}
 	if m.focus == FocusSearchBar {
 		switch msg.String() {
 		case "esc", "ctrl+c":
-			m.input.SetValue(m.prevQuery)
-			m.prevQuery = ""
-			m.SetFocus(FocusLogPane)
+			m.CloseSearch(false)
 		case "enter":
-			m.SetFocus(FocusLogPane)
+			m.CloseSearch(true)
 		case "backspace":
-			if m.Query() == "" {
+			if m.bar().Value() == "" {
 				m.SetFocus(FocusLogPane)
 				return nil
 			}
 			fallthrough
 		default:
-			queryBefore := m.input.Value()
-			newSearch, cmd := m.input.Update(msg)
-			m.input = &newSearch
+			if msg.Paste {
+				msg.Runes = pastedQuery(msg.Runes)
+			}
+			input := m.bar()
+			queryBefore := input.Value()
+			newSearch, cmd := input.Update(msg)
+			*input = newSearch
 			if newSearch.Value() != queryBefore {
-				m.handleSearch()
+				return tea.Batch(cmd, m.handleBarChange())
 			}
 			return cmd
 		}
 		return nil
 	}
 
-	switch msg.String() {
-	case "ctrl+c", "esc":
+	key := msg.String()
+	if m.keys.pushCount(key) {
+		return nil
+	}
+	count, hasCount := m.keys.takeCount()
+	held := m.keys.takeHeld()
+
+	if slices.Contains(m.quitKeys, key) {
 		return tea.Quit
+	}
+
+	switch key {
 	case "w":
-		m.SetWrapMode(!m.shouldHardwrap)
+		return m.ToggleWrapMode()
+	case "s":
+		m.ShowStatusbar(!m.shouldShowStatusbar)
+	case "x":
+		m.SetHexMode(!m.hexMode)
+	case "^":
+		m.anchorStart = !m.anchorStart
+		return m.handleSearch()
+	case "$":
+		m.anchorEnd = !m.anchorEnd
+		return m.handleSearch()
 	case "/":
-		m.prevQuery = m.Query()
-		m.SetQuery("")
-		m.SetFocus(FocusSearchBar)
+		m.OpenSearch()
+	case "?":
+		m.OpenFind()
+	case "*":
+		// `g*` also matches within longer words
+		return m.SearchCurrentWord(held != "g")
+	case "n":
+		return m.NextMatch()
+	case "N":
+		return m.PrevMatch()
+	case "[":
+		if first := m.FirstMatch(); first >= 0 {
+			return m.jumpToMatch(m.viewPosition(first))
+		}
+	case "]":
+		if last := m.LastMatch(); last >= 0 {
+			return m.jumpToMatch(m.viewPosition(last))
+		}
+	case "f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9":
+		return m.applyQuickFilter(int(key[1] - '0'))
 
 	case "up", "k":
-		m.ScrollBy(-1)
+		return m.MoveCursor(-count)
 	case "down", "j":
-		m.ScrollBy(1)
+		return m.MoveCursor(count)
 
 	case "pgup":
-		pageDistance := max(0, m.windowHeight-4)
-		m.ScrollBy(-pageDistance)
+		return m.ScrollBy(-m.pageDistance() * count)
 	case "pgdown":
-		pageDistance := max(0, m.windowHeight-4)
-		m.ScrollBy(pageDistance)
+		return m.ScrollBy(m.pageDistance() * count)
 
 	case "ctrl+u":
-		m.ScrollBy(-m.windowHeight / 2)
+		return m.ScrollBy(-m.halfPageDistance() * count)
 	case "ctrl+d":
-		m.ScrollBy(m.windowHeight / 2)
+		return m.ScrollBy(m.halfPageDistance() * count)
 
 	case "home":
-		m.ScrollTo(0)
+		return m.ScrollTo(0)
 	case "end":
-		m.ScrollTo(-1)
+		return m.goToBottom()
+	case "F":
+		return m.Follow()
 
 	case "g":
-		if m.heldKey == "g" {
+		if held == "g" {
+			// without a count, it's the first line; only where the
+			// viewport ends up is reported
+			before, edge := m.scrollPosition, m.edge
 			m.ScrollTo(0)
-			m.heldKey = ""
-		} else {
-			m.heldKey = "g"
+			m.moveCursorTo(count - 1)
+			m.edge = edge
+			return m.scrollCmd(before)
+		}
+		if hasCount {
+			m.keys.count = count
 		}
+		return m.keys.hold("g")
 	case "G":
-		m.ScrollTo(-1)
+		if hasCount {
+			return m.moveCursorTo(count - 1)
+		}
+		return m.goToBottom()
 	}
 	return nil
 }
 
-func (m *Model) handleMouse(msg tea.MouseMsg) {
+// keyState tracks input spanning several keypresses, like the count in
+// `10j` or the first key of `gg`.
+type keyState struct {
+	// count is the pending count prefix, or 0 if none has been typed.
+	count int
+
+	// heldKey is the first key of a two-key sequence like `gg`. It's let go
+	// by the next key, or after heldKeyTimeout.
+	heldKey string
+
+	// heldGen identifies the latest held key, so that the timeouts of
+	// earlier ones are ignored.
+	heldGen int
+}
+
+// heldKeyTimeout is how long the first key of a two-key sequence like `gg`
+// waits for the second.
+const heldKeyTimeout = time.Second
+
+// heldKeyTimeoutMsg lets go of a held key if it's still the one with
+// generation gen.
+type heldKeyTimeoutMsg struct{ gen int }
+
+// hold holds key as the first of a two-key sequence, returning a command
+// that lets it go after heldKeyTimeout.
+func (k *keyState) hold(key string) tea.Cmd {
+	k.heldKey = key
+	k.heldGen++
+	gen := k.heldGen
+	return tea.Tick(heldKeyTimeout, func(time.Time) tea.Msg { return heldKeyTimeoutMsg{gen} })
+}
+
+// takeHeld returns and lets go of the held key, if any.
+func (k *keyState) takeHeld() string {
+	held := k.heldKey
+	k.heldKey = ""
+	return held
+}
+
+// handleHeldKeyTimeout lets go of the held key, and any count typed before
+// it, if it's still the one the timeout was for.
+func (k *keyState) handleHeldKeyTimeout(msg heldKeyTimeoutMsg) {
+	if msg.gen == k.heldGen && k.heldKey != "" {
+		k.heldKey, k.count = "", 0
+	}
+}
+
+// pushCount accumulates key into the pending count if it's a digit,
+// reporting whether it was consumed. A leading 0 isn't treated as a count.
+func (k *keyState) pushCount(key string) bool {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' || (key == "0" && k.count == 0) {
+		return false
+	}
+	k.count = min(k.count*10+int(key[0]-'0'), 1_000_000)
+	return true
+}
+
+// takeCount returns and clears the pending count, defaulting to 1. ok
+// reports whether a count was actually typed.
+func (k *keyState) takeCount() (count int, ok bool) {
+	count, ok = max(1, k.count), k.count > 0
+	k.count = 0
+	return count, ok
+}
+
+func (m *Model) handleMouse(msg tea.MouseMsg) tea.Cmd {
 	switch msg.Button {
 	case tea.MouseButtonWheelDown:
-		m.ScrollBy(1)
+		return m.ScrollBy(m.wheel.distance(time.Now(), 1))
 	case tea.MouseButtonWheelUp:
-		m.ScrollBy(-1)
+		return m.ScrollBy(m.wheel.distance(time.Now(), -1))
 	}
+	return nil
 }
 
-func (m *Model) handleWrite(content string) {
-	scanner := bufio.NewScanner(strings.NewReader(content))
+// maxWheelScrollLines bounds how many lines a wheel event can scroll by
+// before acceleration.
+const maxWheelScrollLines = 100
 
-	// In order to deal with an existing buffer, we'll manually handle the
-	// first line before looping over the rest of the scan.
+// Wheel events coming within wheelAccelWindow of each other, in the same
+// direction, gradually speed scrolling up to maxWheelAccel times faster.
+const (
+	wheelAccelWindow = 50 * time.Millisecond
+	maxWheelAccel    = 4
+)
 
-	// If the write is just the empty string, there's nothing to do.
-	if !scanner.Scan() {
-		return
+// SetWheelScrollLines sets how many lines each mouse wheel event scrolls
+// by, from 1, the default, to 100.
+func (m *Model) SetWheelScrollLines(lines int) {
+	m.wheel.lines = clamp(1, maxWheelScrollLines, lines)
+}
+
+// SetWheelAcceleration sets whether scrolling with the mouse wheel speeds
+// up, to as much as 4 times as many lines per event, while wheel events
+// keep coming in quick succession.
+func (m *Model) SetWheelAcceleration(accelerate bool) { m.wheel.accelerate = accelerate }
+
+// wheelState tracks scrolling with the mouse wheel.
+type wheelState struct {
+	// lines is how many lines each event scrolls by, or 0 for 1.
+	lines      int
+	accelerate bool
+
+	// streak counts the events in a row that came in quick succession in
+	// direction dir, the last of them at last.
+	streak int
+	dir    int
+	last   time.Time
+}
+
+// distance returns how many lines to scroll by for a wheel event at now,
+// in direction dir, which is 1 for down and -1 for up.
+func (w *wheelState) distance(now time.Time, dir int) int {
+	lines := max(1, w.lines)
+	if !w.accelerate {
+		return dir * lines
+	}
+	if dir == w.dir && now.Sub(w.last) < wheelAccelWindow {
+		w.streak++
+	} else {
+		w.streak = 0
+	}
+	w.dir, w.last = dir, now
+	// speed up by another multiple of lines every few events
+	return dir * lines * min(1+w.streak/4, maxWheelAccel)
+}
+
+// handleWrite appends content, written to stream, to the log.
+func (m *Model) handleWrite(stream Stream, content string) {
+	defer m.startWrite(stream)()
+	writeLines(m, stream, content, strings.IndexByte)
+}
+
+// handleWriteBytes is like handleWrite, but for content as bytes.
+func (m *Model) handleWriteBytes(stream Stream, content []byte) {
+	defer m.startWrite(stream)()
+	writeLines(m, stream, content, bytes.IndexByte)
+}
+
+// startWrite prepares for content written to stream, and returns a function
+// to call once it's all been written.
+func (m *Model) startWrite(stream Stream) (finish func()) {
+	// In reverse, new lines are inserted at the top of the view, so keep
+	// the viewport on the same content by shifting it down with them.
+	var shifts []func()
+	for _, v := range m.views {
+		if v.reverse && v.scrollPosition >= 0 {
+			before := v.viewLen()
+			shifts = append(shifts, func() {
+				shift := v.viewLen() - before
+				v.scrollPosition += shift
+				v.cursor += shift
+			})
+		}
+	}
+
+	// Lines can't be made up of more than one stream, so a write to
+	// another stream completes any incomplete line.
+	linesBefore := m.lineCount()
+	if m.buffer != "" && stream != m.bufferStream {
+		m.appendLine(strings.TrimSuffix(m.buffer, "\r"), m.bufferStream)
+		m.buffer = ""
+	}
+	m.bufferStream = stream
+	if stream != StreamStdout {
+		m.multipleStreams = true
 	}
 
-	// If the first thing we scan is a newline, flush the buffer.
-	// Otherwise, add it to the buffer and then flush.
-	text := scanner.Text()
-	m.lines, m.buffer = append(m.lines, m.buffer+text), ""
-	if result := m.searchLine(len(m.lines) - 1); result != nil {
-		m.filtered = append(m.filtered, *result)
+	return func() {
+		m.spill()
+		// count the lines written for anyone tracking the rate
+		now := time.Now()
+		for _, v := range m.views {
+			if v.rate != nil {
+				v.rate.add(now, v.lineCount()-linesBefore)
+			}
+		}
+		for _, shift := range shifts {
+			shift()
+		}
 	}
+}
 
-	// Now handle the rest of the lines.
-	for scanner.Scan() {
-		text := scanner.Text()
-		m.lines = append(m.lines, text)
-		if result := m.searchLine(len(m.lines) - 1); result != nil && strings.HasSuffix(text, "\n") {
-			m.filtered = append(m.filtered, *result)
+// writeLines splits content into lines with index, which finds a byte in
+// it, and appends them to the log. Each line is converted to a string once,
+// however content was written.
+func writeLines[T string | []byte](m *Model, stream Stream, content T, index func(T, byte) int) {
+	for {
+		// Each "\n" completes a line, starting with whatever was already
+		// in the buffer. So a lone "\n" completes the buffered line, or
+		// writes an empty line if nothing was buffered.
+		i := index(content, '\n')
+		if i < 0 {
+			// The write didn't end with a newline, so whatever's left
+			// over is an incomplete line; keep it in the buffer.
+			text := string(content)
+			m.sniff(text)
+			m.buffer += text
+			return
 		}
+		text := string(content[:i])
+		m.sniff(text)
+		// Accept CRLF line endings too. The "\r" may have been written
+		// separately, so it could be at the end of the buffer.
+		line := m.buffer + text
+		m.appendLine(strings.TrimSuffix(line, "\r"), stream)
+		m.buffer = ""
+		content = content[i+1:]
+	}
+}
+
+// appendLine adds a complete line, written to stream, to the end of the log.
+func (m *Model) appendLine(line string, stream Stream) {
+	if stream != StreamStdout {
+		// streams is only as long as it needs to be; any lines past its
+		// end are from stdout
+		m.streams = append(m.streams, make([]Stream, m.lineCount()-len(m.streams))...)
+		m.streams = append(m.streams, stream)
+	}
+	m.lines = append(m.lines, line)
+	for _, v := range m.views {
+		if v.filtering() {
+			v.filterFrom(v.lineCount() - 1)
+		}
+	}
+}
+
+// ReplaceLine replaces the complete line at index with content, for logs
+// that update lines in place, like progress bars or status displays. Views
+// update whether the line passes their filter. Lines that are out of range,
+// or that have been spilled to disk, are left alone.
+//
+// While a search is running in the background, the lines in memory are
+// copied rather than modified, so that it keeps seeing the log as it was.
+func (m *Model) ReplaceLine(index int, content string) {
+	spilled := 0
+	if m.disk != nil {
+		spilled = m.disk.len()
+	}
+	if index < spilled || index >= m.lineCount() {
+		return
+	}
+	content = strings.TrimSuffix(strings.TrimSuffix(content, "\n"), "\r")
+
+	if m.shared {
+		m.lines, m.shared = slices.Clone(m.lines), false
+	}
+	m.lines[index-spilled] = content
+	for _, v := range m.views {
+		v.occurrences, v.matchCache = occurrenceCounts{}, matchCache{}
+		if !v.filtering() {
+			continue
+		}
+		if v.multilining() || v.showingContext() {
+			// the line may affect which others are in the view
+			v.setFiltered(v.search())
+			continue
+		}
+		i, found := slices.BinarySearch(v.filtered, index)
+		if matched := v.matchLine(index); matched && !found {
+			v.filtered = slices.Insert(v.filtered, i, index)
+		} else if !matched && found {
+			v.filtered = slices.Delete(v.filtered, i, i+1)
+		}
+	}
+}
+
+// spill moves the oldest lines out of memory and onto disk, if disk backing
+// is enabled and enough lines have accumulated.
+func (m *Model) spill() {
+	if m.disk == nil || len(m.lines) < 2*m.disk.maxLines {
+		return
+	}
+	n := len(m.lines) - m.disk.maxLines
+	if err := m.disk.append(m.lines[:n]); err != nil {
+		return
+	}
+	m.lines = append([]string(nil), m.lines[n:]...)
+}
+
+// line returns the complete line at index i, reading it back from disk if
+// it has been spilled.
+func (m *Model) line(i int) string { return m.snapshot().line(i) }
+
+// lineCount returns the number of complete lines, including any that have
+// been spilled to disk.
+func (m *Model) lineCount() int { return m.snapshot().len() }
+
+// snapshot returns the complete lines written so far.
+func (m *Model) snapshot() lineSnapshot {
+	s := lineSnapshot{lines: m.lines, streams: m.streams}
+	if m.disk != nil {
+		s.disk = *m.disk
+	}
+	return s
+}
+
+// sharedSnapshot is like snapshot, for reading from another goroutine. The
+// lines in it are copied before any are next replaced.
+func (m *Model) sharedSnapshot() lineSnapshot {
+	m.shared = true
+	return m.snapshot()
+}
+
+// lineSnapshot is a read-only view of the complete lines at some point in
+// time. Since lines are only ever appended, or replaced by copying them once
+// shared, it's safe to read from another goroutine while more lines are
+// written.
+type lineSnapshot struct {
+	disk    diskStore
+	lines   []string
+	streams []Stream
+}
+
+func (s lineSnapshot) line(i int) string {
+	spilled := s.disk.len()
+	if i < spilled {
+		return s.disk.line(i)
 	}
-	if err := scanner.Err(); err != nil {
-		panic(err)
+	return s.lines[i-spilled]
+}
+
+// read is like line, but returns the error if the line can't be read back
+// from disk.
+func (s lineSnapshot) read(i int) (string, error) {
+	spilled := s.disk.len()
+	if i < spilled {
+		return s.disk.read(i)
 	}
+	return s.lines[i-spilled], nil
+}
 
-	// If the write didn't end with a newline, we overshot: the last line
-	// we scanned should actually be the new buffer.
-	if len(m.lines) > 0 && !strings.HasSuffix(content, "\n") {
-		m.buffer = m.lines[len(m.lines)-1]
-		m.lines = m.lines[:len(m.lines)-1]
+func (s lineSnapshot) len() int { return s.disk.len() + len(s.lines) }
+
+// stream returns the stream that the line at index i was written to.
+func (s lineSnapshot) stream(i int) Stream {
+	if i < len(s.streams) {
+		return s.streams[i]
 	}
+	return StreamStdout
 }
 
-func (m *Model) handleSearch() {
+// searchNow applies the current query immediately, blocking until the
+// whole log has been searched.
+func (m *Model) searchNow() {
+	m.cancelSearch()
 	query := m.input.Value()
 
 	if query == "" {
 		m.queryRe = nil
+	} else if queryRe, err := m.compileQuery(query); err == nil {
+		m.queryRe = queryRe
+	}
+	m.setFiltered(m.search())
+}
+
+// handleSearch applies the current query. Filtering the whole log is done
+// in the background by the returned command, so that typing stays
+// responsive on huge logs; the results are applied when its
+// [filterDoneMsg] is handled by Update. Until then, the previous results
+// are displayed.
+func (m *Model) handleSearch() tea.Cmd {
+	query := m.input.Value()
+	if query == "" || m.searchScope == SearchScopeViewport {
+		// there's nothing expensive to do
+		m.searchNow()
+		return nil
+	}
+
+	queryRe, err := m.compileQuery(query)
+	if err != nil {
+		// keep showing the results of the last valid query
+		return nil
+	}
+
+	m.cancelSearch()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if m.searchTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), m.searchTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	m.searchCancel, m.pendingRe = cancel, queryRe
+
+	gen, lines, search := m.searchGen, m.sharedSnapshot(), m.searchFunc(queryRe)
+	return func() tea.Msg {
+		filtered, err := search(ctx, lines, nil)
+		return filterDoneMsg{gen, queryRe, filtered, lines.len(), err}
+	}
+}
+
+// filterDoneMsg carries the results of a search started by handleSearch.
+type filterDoneMsg struct {
+	// gen is the search generation the results belong to; if the query
+	// has changed since, they're stale.
+	gen      uint64
+	queryRe  *regexp.Regexp
+	filtered []int
+
+	// searched is the number of lines that were searched. Lines written
+	// since the search started still need to be checked.
+	searched int
+	err      error
+}
+
+// searchSnapshot appends the indices of the lines for which match returns
+// true to results, giving up if ctx is cancelled.
+func searchSnapshot(ctx context.Context, match func(string, Stream) bool, lines lineSnapshot, results []int) ([]int, error) {
+	for i := range lines.len() {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if match(lines.line(i), lines.stream(i)) {
+			results = append(results, i)
+		}
+	}
+	return results, nil
+}
+
+func (m *Model) handleFilterDone(msg filterDoneMsg) {
+	// discard the results of searches that were since replaced
+	if msg.gen != m.searchGen {
+		return
+	}
+	pendingScroll := m.pendingScroll
+	m.cancelSearch()
+	if msg.err != nil {
+		m.searchTimedOut = errors.Is(msg.err, context.DeadlineExceeded)
 		return
 	}
 
-	if queryRe, err := regexp.Compile(query); err == nil {
-		m.queryRe = queryRe
+	m.queryRe = msg.queryRe
+	m.setFiltered(msg.filtered)
+	m.filterFrom(msg.searched)
+	if pendingScroll != nil {
+		m.restoreScroll(*pendingScroll)
+	}
+}
+
+// cancelSearch stops any search running in the background, and makes sure
+// its results are discarded if they arrive anyway.
+func (m *Model) cancelSearch() {
+	if m.searchCancel != nil {
+		m.searchCancel()
 	}
-	m.filtered = m.search()
+	m.searchGen++
+	m.searchCancel, m.pendingRe, m.pendingScroll = nil, nil, nil
+	m.matchTotal = 0
+	m.searchTimedOut = false
+}
+
+// SetSearchTimeout limits how long a search typed into the search bar may
+// run before it's abandoned, in case a query is pathologically slow on a
+// huge log. Zero, the default, means no limit.
+func (m *Model) SetSearchTimeout(timeout time.Duration) { m.searchTimeout = max(0, timeout) }
+
+// compileQuery compiles the regular expression for query, taking into
+// account any modes that change how the query is interpreted or matched.
+func (m *Model) compileQuery(query string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(m.searchPattern(query))
+	if err == nil && m.posixMatch {
+		re.Longest()
+	}
+	return re, err
+}
+
+// SetRegexpSyntax sets whether the query and find match leftmost-longest,
+// like POSIX regular expressions, rather than preferring the leftmost
+// alternative like Perl's. For example, against "foobar", `foo|foobar`
+// matches "foobar" rather than "foo". The syntax accepted is the same
+// either way.
+func (m *Model) SetRegexpSyntax(posix bool) {
+	m.posixMatch = posix
+	m.searchNow()
+	m.applyFind()
+}
+
+// searchPattern returns the regular expression for query, taking into
+// account any modes that change how the query is interpreted.
+func (m *Model) searchPattern(query string) string {
+	if m.anchorStart {
+		query = "^(?:" + query + ")"
+	}
+	if m.anchorEnd {
+		query = "(?:" + query + ")$"
+	}
+	if m.multilineMatch {
+		// anchors match at every line of the joined log
+		query = "(?m)" + query
+	}
+	return query
 }
 
 func New(mods ...func(*Model)) *Model {
 	inp := textinput.New()
 	inp.Prompt = "/"
+	findInp := textinput.New()
+	findInp.Prompt = "?"
 
 	m := &Model{
-		scrollPosition:      -1,
-		shouldShowStatusbar: true,
-		input:               &inp,
+		scrollPosition:         -1,
+		outputLineEnding:       "\n",
+		statusSeparator:        "  ",
+		quitKeys:               []string{"ctrl+c", "esc"},
+		shouldShowStatusbar:    true,
+		shouldShowPartialLines: true,
+		noColor:                noColorFromEnv(),
+		input:                  &inp,
+		findInput:              &findInp,
+		lineStore:              &lineStore{},
 	}
+	m.views = []*Model{m}
 	for _, mod := range mods {
 		mod(m)
 	}
@@ -437,9 +1900,36 @@ func New(mods ...func(*Model)) *Model {
 }
 
 func WithoutStatusbar(m *Model)    { m.shouldShowStatusbar = false }
+func WithoutQuitKeys(m *Model)     { m.quitKeys = nil }
 func WithStartAtHead(m *Model)     { m.scrollPosition = 0 }
 func WithSoftWrap(m *Model) *Model { m.shouldHardwrap = false; return m }
 
+// WithDiskBacking keeps memory bounded for huge logs by spilling older lines
+// to a temporary file in dir, reading them back as they're displayed or
+// searched. If dir is empty, the default temporary directory is used. The
+// newest maxLines lines are kept in memory, or 10,000 if maxLines isn't
+// positive. Call [Model.Close] to remove the file, and see [Model.DiskErr]
+// for whether it's working.
+func WithDiskBacking(dir string, maxLines int) func(*Model) {
+	if maxLines <= 0 {
+		maxLines = defaultDiskBackingMaxLines
+	}
+	return func(m *Model) {
+		m.disk = &diskStore{dir: dir, maxLines: maxLines, failure: &diskFailure{}}
+	}
+}
+
+// DiskErr returns the first error reading or writing the file used for
+// disk backing, if there's been one. Lines that couldn't be written to it
+// are kept in memory, and lines that couldn't be read back are displayed
+// as empty. The statusbar shows [disk error] once this happens.
+func (m *Model) DiskErr() error {
+	if m.disk == nil {
+		return nil
+	}
+	return m.disk.err()
+}
+
 // [Model] implements [tea.Model]
 var _ tea.Model = &Model{}
 
@@ -447,17 +1937,232 @@ type Model struct {
 	windowWidth  int
 	windowHeight int
 
-	shouldHardwrap      bool
-	shouldShowStatusbar bool
+	// renderWidth and renderHeight, if set by SetContentDimensions,
+	// override the window size as the area View renders into.
+	renderWidth  int
+	renderHeight int
+
+	shouldHardwrap         bool
+	shouldShowStatusbar    bool
+	shouldShowPartialLines bool
+
+	// freezeOnSearch pauses tailing while the search bar is focused;
+	// tailPaused is set while it's paused.
+	freezeOnSearch bool
+	tailPaused     bool
+
+	// noCountPartial leaves the incomplete last line out of the line count
+	// in the statusbar.
+	noCountPartial bool
+
+	// relativeLineNumbers shows a gutter with each line's distance from
+	// the current line, like vim's `relativenumber`.
+	relativeLineNumbers bool
+	gutterWidth         int
+
+	// wrapStyle chooses how lines are broken when soft wrapping.
+	wrapStyle WrapStyle
+
+	// hangingIndent indents soft-wrapped continuation rows to match the
+	// leading whitespace of their line.
+	hangingIndent bool
+
+	// wrapCache holds the wrapped form of recently rendered lines, valid
+	// for wrapCacheWidth, wrapCacheHardwrap, wrapCacheStyle, and
+	// wrapCacheIndent.
+	wrapCache         map[string]wrapResult
+	wrapCacheWidth    int
+	wrapCacheHardwrap bool
+	wrapCacheStyle    WrapStyle
+	wrapCacheIndent   bool
+
+	// highlightCache holds recently rendered lines with the query's
+	// matches highlighted, valid for highlightCacheRe and
+	// highlightCacheNoColor.
+	highlightCache        map[string]string
+	highlightCacheRe      *regexp.Regexp
+	highlightCacheNoColor bool
+
+	// reverse displays the newest lines first. Tailing pins the view to
+	// the top instead of the bottom.
+	reverse bool
+
+	// shouldShowInvisibles renders whitespace and control characters with
+	// visible stand-ins.
+	shouldShowInvisibles bool
+
+	// colorizer, if set, styles each line as it's rendered.
+	colorizer Colorizer
+
+	// noColor removes all color from the output.
+	noColor bool
+
+	// hexMode displays lines as hexdumps.
+	hexMode bool
+
+	// rate, if set, tracks how fast lines are being written.
+	rate *lineRate
+
+	// columnDelimiter and columnFields select which fields of each line
+	// are displayed. If columnFields is nil, lines are displayed whole.
+	columnDelimiter string
+	columnFields    []int
+
+	// extractRe, if set, displays lines as columns of its capture groups,
+	// extractWidths wide. Lines it doesn't match are hidden if
+	// hideUnextracted is set.
+	extractRe       *regexp.Regexp
+	extractWidths   []int
+	hideUnextracted bool
+
+	// shouldShowMatchGutter marks matching lines in a column alongside the
+	// log.
+	shouldShowMatchGutter bool
+
+	// shouldShowMinimap draws a column marking where in the log matches
+	// are, alongside the viewport.
+	shouldShowMinimap bool
+
+	// shouldShowRuler pins a row numbering the columns of the log to the
+	// top of the viewport.
+	shouldShowRuler bool
+
+	// stickyHeader is the number of lines at the start of the log that are
+	// pinned to the top of the viewport.
+	stickyHeader int
 
 	focus FocusArea
 
-	input     *textinput.Model
-	queryRe   *regexp.Regexp
-	prevQuery string
-
-	// state for two-key inputs like `gg`
-	heldKey string
+	input       *textinput.Model
+	queryRe     *regexp.Regexp
+	prevQuery   string
+	searchScope SearchScope
+
+	// rememberedScrolls are where the viewport was scrolled to with each
+	// of the most recently applied queries, least recent first, if
+	// rememberScroll is set. pendingScroll is restored once the search in
+	// the background finishes.
+	rememberScroll    bool
+	rememberedScrolls []rememberedScroll
+	pendingScroll     *scrollMark
+
+	// findInput and findRe are the find, which highlights matches within
+	// the view rather than narrowing it. editingFind is set while it's
+	// being typed into the search bar.
+	findInput   *textinput.Model
+	findRe      *regexp.Regexp
+	prevFind    string
+	editingFind bool
+
+	// filterInclude and filterExclude, if set, narrow the view to lines
+	// that match and don't match them, respectively.
+	filterInclude *regexp.Regexp
+	filterExclude *regexp.Regexp
+
+	// fieldExtractor decodes lines into fields, and fieldRe, if set,
+	// narrows the view to lines whose field fieldKey matches it.
+	fieldExtractor func(line string) map[string]string
+	fieldKey       string
+	fieldRe        *regexp.Regexp
+
+	// After jumping to a match, jumpedMatch is the index of its line, and
+	// it's match number matchOrdinal of matchTotal. The matches are
+	// counted from matchCache.
+	jumpedMatch  int
+	matchOrdinal int
+	matchTotal   int
+	matchCache   matchCache
+
+	// shouldShowOccurrences shows the number of matching lines and matches
+	// in the statusbar, which are counted in occurrences.
+	shouldShowOccurrences bool
+	occurrences           occurrenceCounts
+
+	// quickFilters are patterns stashed in slots 1 to 9, applied with the
+	// function keys.
+	quickFilters [9]string
+
+	// timeDisplay is how the timestamps of lines are shown in the gutter.
+	// The time of the first line with a timestamp is cached in firstTime
+	// once found, having searched the first startTimeSearched lines.
+	timeDisplay       TimeDisplay
+	firstTime         time.Time
+	startTimeFound    bool
+	startTimeSearched int
+
+	// contextBefore and contextAfter are how many lines of context are
+	// shown around each line passing the filter. contextLines holds the
+	// indices of the lines in filtered that are only there as context.
+	contextBefore int
+	contextAfter  int
+	contextLines  map[int]struct{}
+
+	// dimNonMatches renders lines not matching the query dimmed.
+	dimNonMatches bool
+
+	// quitKeys quit the program when pressed in the log pane.
+	quitKeys []string
+
+	// diffHighlight highlights what changed from the line before.
+	diffHighlight bool
+
+	// dedent trims the leading whitespace common to the lines on screen
+	// from them.
+	dedent bool
+
+	// matchStripANSI matches the query against lines without their escape
+	// sequences.
+	matchStripANSI bool
+
+	// pageAlignment is where a partial page of lines is shown.
+	pageAlignment PageAlignment
+
+	// noOverscroll stops scrolling once the last line is at the bottom of
+	// the viewport.
+	noOverscroll bool
+
+	// blurred is set when another component of the program has focus, so
+	// key presses are ignored.
+	blurred bool
+
+	// stayOnBottom makes `G` and `end` scroll to the bottom of the log
+	// without tailing it.
+	stayOnBottom bool
+
+	// tickInterval is how often the model re-renders on its own, if at
+	// all. tickGen is incremented when it changes, to stop old ticks.
+	tickInterval time.Duration
+	tickGen      uint64
+
+	// multilineMatch matches the query against the log as a whole.
+	multilineMatch bool
+
+	// posixMatch makes the query and find match leftmost-longest.
+	posixMatch bool
+
+	// anchorStart and anchorEnd anchor the query to the start and end of
+	// the line, without having to type `^` and `$`.
+	anchorStart bool
+	anchorEnd   bool
+
+	// While a search runs in the background, pendingRe is its query.
+	// searchGen is bumped whenever a search is started or cancelled, so
+	// that stale results can be discarded.
+	searchGen      uint64
+	searchCancel   context.CancelFunc
+	pendingRe      *regexp.Regexp
+	searchTimeout  time.Duration
+	searchTimedOut bool
+
+	// state for multi-key inputs like `10j` and `gg`
+	keys keyState
+
+	// state for scrolling with the mouse wheel
+	wheel wheelState
+
+	// edge is the edge of the view the viewport was at when an EdgeMsg
+	// was last considered, if any.
+	edge Edge
 
 	// ScrollPosition tracks the position of the viewport relative to the
 	// log's content.
@@ -469,28 +2174,378 @@ type Model struct {
 	scrollPosition int
 
 	firstDisplayedLine int
+	lastDisplayedLine  int
+
+	// bodyWidth and bodyHeight are the size of the area lines were last
+	// rendered into.
+	bodyWidth  int
+	bodyHeight int
+
+	// If the line at index rowOffsetLine is at the top of the viewport, its
+	// first rowOffset wrapped rows are scrolled out of view. This lets a
+	// line taller than the viewport be scrolled to a match within it.
+	rowOffset     int
+	rowOffsetLine int
+
+	// topAnchor keeps the row at the top of the viewport in place as the
+	// width changes. See keepTopRow.
+	topAnchor rowAnchor
+
+	// centerOnMatch centers the viewport on matches jumped to.
+	centerOnMatch bool
+
+	// cursor is the position of the current line within the current view.
+	// It is only meaningful while not tailing.
+	cursor int
+
+	// scrollOff is the number of lines of context kept visible above and
+	// below the cursor, like vim's `scrolloff`.
+	scrollOff int
+
+	// lineStore holds the log's content, which is shared with any forks.
+	*lineStore
 
+	// filtered contains the indices into lines of every line matching
+	// queryRe, in ascending order.
+	filtered []int
+
+	// folds are the ranges of lines collapsed into a single row, sorted
+	// and non-overlapping.
+	folds []foldRange
+
+	// streamFilter, if non-nil, narrows the view to lines written to the
+	// given streams.
+	streamFilter []Stream
+
+	// outputLineEnding separates lines when the log is exported.
+	outputLineEnding string
+
+	// statusSeparator separates the parts of the statusbar.
+	statusSeparator string
+}
+
+// lineStore holds the content of a log, which may be shared by several
+// views of it; see [Model.Fork].
+type lineStore struct {
 	// lines contains all complete lines (that is, a "\n" was written to
-	// end the line).
-	lines    []string
-	filtered []string
+	// end the line). With disk backing, the oldest lines are in disk
+	// instead; use line and lineCount to access them.
+	lines []string
+	disk  *diskStore
+
+	// shared is set when lines may be being read from another goroutine,
+	// so they need copying before a line is replaced.
+	shared bool
 
 	// If the most recent character written was not a "\n", buffer contains
-	// everything that was written since the last "\n".
-	buffer string
+	// everything that was written since the last "\n", to bufferStream.
+	buffer       string
+	bufferStream Stream
+
+	// streams contains the stream each line was written to, by line
+	// index. Lines past its end were written to stdout, so it stays empty
+	// unless other streams are used, which multipleStreams records.
+	streams         []Stream
+	multipleStreams bool
+
+	// looksBinary is set if the first sniffed bytes of the log,
+	// sniffInvalid of which were invalid UTF-8, look like binary content.
+	looksBinary  bool
+	sniffed      int
+	sniffInvalid int
+
+	// atEOF is set once the source of the log has been exhausted.
+	atEOF bool
+
+	// views are the models displaying the log, which need to know about
+	// new lines.
+	views []*Model
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return m.tick()
 }
 
 func (m *Model) String() string {
-	return strings.Join(m.content(), "\n")
+	return strings.Join(m.content(), m.outputLineEnding)
+}
+
+// SaveToFile writes the log to the named file, ending each complete line
+// with the output line ending. Lines are written as they're read, so that
+// a log spilled to disk isn't read back into memory all at once.
+func (m *Model) SaveToFile(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	lines := m.snapshot()
+	for i := range lines.len() {
+		line, err := lines.read(i)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.WriteString(line)
+		w.WriteString(m.outputLineEnding)
+	}
+	w.WriteString(m.buffer)
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SetStatusSeparator sets what separates the line status from the search
+// status in the statusbar. It may be styled, like a dim "│". The default
+// is two spaces.
+func (m *Model) SetStatusSeparator(separator string) { m.statusSeparator = separator }
+
+// SetOutputLineEnding sets the line ending used by String and SaveToFile,
+// like "\r\n" for Windows tools. The default is "\n".
+func (m *Model) SetOutputLineEnding(ending string) { m.outputLineEnding = ending }
+
+func (m *Model) Write(content string) {
+	m.handleWrite(StreamStdout, content)
+}
+
+// WriteLine appends line to the log as a complete line, for callers with
+// discrete records rather than a stream of text. Unlike with Write, line
+// is never joined to an incomplete line written before: that's completed
+// first, as a line of its own. A trailing line ending is optional, and if
+// line contains other newlines, it's split at them into several lines.
+func (m *Model) WriteLine(line string) {
+	if m.buffer != "" {
+		m.handleWrite(m.bufferStream, "\n")
+	}
+	line = strings.TrimSuffix(line, "\n")
+	m.handleWrite(StreamStdout, line+"\n")
+}
+
+// WriteMsg writes its content to the log when passed to Update, like Write.
+// It's for writing from outside the program, with [tea.Program.Send].
+type WriteMsg string
+
+// WriteBatchMsg is like WriteMsg, but writes several pieces of content at
+// once, which is cheaper than sending them one by one when they pile up.
+type WriteBatchMsg []string
+
+// writeBatch writes each piece of content in batch, in order.
+func (m *Model) writeBatch(batch []string) {
+	defer m.startWrite(StreamStdout)()
+	for _, content := range batch {
+		writeLines(m, StreamStdout, content, strings.IndexByte)
+	}
+}
+
+// WriteBytes is like Write, but avoids converting content to a string.
+func (m *Model) WriteBytes(content []byte) {
+	m.handleWriteBytes(StreamStdout, content)
+}
+
+// WriteStream is like Write, but tags the written lines with the stream
+// they came from, like a subprocess's stderr. Lines are styled by stream,
+// and can be filtered by stream with [Model.SetStreamFilter].
+func (m *Model) WriteStream(stream Stream, content string) {
+	m.handleWrite(stream, content)
+}
+
+// Close releases any resources held by the model, like the file used for
+// disk backing. Resources shared with forks are released once every fork
+// is closed too.
+func (m *Model) Close() error {
+	m.cancelSearch()
+	m.views = slices.DeleteFunc(m.views, func(v *Model) bool { return v == m })
+	if m.disk != nil && len(m.views) == 0 {
+		return m.disk.close()
+	}
+	return nil
+}
+
+// Fork returns a new view of the same log, with default settings. Both
+// display everything written to either, but scroll, search, and so on
+// independently, as for side-by-side panes of the same stream.
+//
+// Like the rest of the model, forks are not safe for concurrent use: they
+// must all be used from the goroutine running the program's Update.
+func (m *Model) Fork() *Model {
+	fork := New()
+	fork.lineStore = m.lineStore
+	m.views = append(m.views, fork)
+	return fork
+}
+
+// MarkEOF records that the source of the log has ended. This is indicated
+// in the statusbar rather than written into the log.
+func (m *Model) MarkEOF() { m.atEOF = true }
+
+func (m *Model) AtEOF() bool { return m.atEOF }
+
+// Writer returns an [io.Writer] that writes to the log, for use with things
+// like [log.SetOutput] or [exec.Cmd.Stdout].
+//
+// Like the rest of the model, the writer is not safe for concurrent use: it
+// must only be written to from the goroutine running the program's Update.
+// To write from elsewhere, send the content to the program as a message and
+// call Write when handling it.
+func (m *Model) Writer() io.Writer { return writer{m, StreamStdout} }
+
+// StreamWriter is like Writer, but tags the written lines with stream, like
+// [Model.WriteStream].
+func (m *Model) StreamWriter(stream Stream) io.Writer { return writer{m, stream} }
+
+type writer struct {
+	m      *Model
+	stream Stream
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	w.m.handleWriteBytes(w.stream, p)
+	return len(p), nil
+}
+
+func (m *Model) SetDimensions(width, height int) {
+	before, _ := m.viewSize()
+	m.windowWidth, m.windowHeight = max(0, width), max(0, height)
+	m.keepTopRow(before)
+}
+
+// SetContentDimensions sets the exact size of the area View renders into,
+// for when the model is placed within a larger layout, like a bordered box,
+// rather than filling the window. Everything View renders, including the
+// statusbar and the Log style's padding, border, and margins, fits within
+// it, so the embedder only needs to subtract its own frame.
+//
+// It overrides the window size set by [Model.SetDimensions], which
+// [tea.WindowSizeMsg] updates, until zero is passed for both.
+func (m *Model) SetContentDimensions(width, height int) {
+	before, _ := m.viewSize()
+	m.renderWidth, m.renderHeight = max(0, width), max(0, height)
+	m.keepTopRow(before)
+}
+
+// keepTopRow keeps the same content at the top of the viewport after its
+// width changes from before. The line at the top stays there regardless,
+// but if it's scrolled partly out of view, lines wrap to a different
+// number of rows at the new width. So the characters scrolled out of view
+// are counted at the width lines were last rendered at, and the next
+// render scrolls out the rows they take up at the new width.
+func (m *Model) keepTopRow(before int) {
+	after, _ := m.viewSize()
+	if after == before || m.bodyWidth == 0 || m.topRowOffset() == 0 || m.topRowAnchored() {
+		// if the anchor is already set, it's kept, so that resizing back
+		// and forth comes back to the same row
+		return
+	}
+	width := m.contentWidth(m.bodyWidth)
+	line := m.displayLine(defaultStyles, m.rowOffsetLine, m.visibleIndent(m.bodyHeight))
+	m.topAnchor = rowAnchor{
+		line:  m.rowOffsetLine,
+		chars: m.charsAbove(line, m.rowOffset, width),
+		rows:  m.rowOffset,
+		width: width,
+	}
+}
+
+// rowAnchor pins a wrapped row of a line by the characters before it, so
+// that the row can be found again when the line is wrapped to a different
+// width.
+type rowAnchor struct {
+	line  int // index of the line
+	chars int // how many of its characters are on the rows above
+	// rows is the row the anchor was last found on, wrapped to width.
+	rows, width int
+}
+
+// restoreTopRow scrolls the rows above the anchored row of the line at the
+// top of the viewport out of view, now that lines are wrapped to width.
+func (m *Model) restoreTopRow(width int, dedent string) {
+	if !m.topRowAnchored() || m.topAnchor.width == width {
+		return
+	}
+	line := m.displayLine(defaultStyles, m.rowOffsetLine, dedent)
+	m.rowOffset = m.rowOfChar(line, m.topAnchor.chars, width)
+	m.topAnchor.rows, m.topAnchor.width = m.rowOffset, width
+}
+
+// topRowAnchored reports whether the viewport hasn't scrolled since the
+// row at its top was last found from topAnchor.
+func (m *Model) topRowAnchored() bool {
+	pos, anchor := m.scrollPosition, m.topAnchor
+	return anchor.chars > 0 && pos >= 0 && pos < m.viewLen() && m.viewIndex(pos) == anchor.line &&
+		anchor.line == m.rowOffsetLine && anchor.rows == m.rowOffset
 }
 
-func (m *Model) Write(content string) { m.handleWrite(content) }
+// lineChar is a character of a line: where it ends, and whether it's
+// whitespace.
+type lineChar struct {
+	end   int
+	space bool
+}
+
+// lineChars splits line into characters, leaving out escape sequences.
+func lineChars(line string) []lineChar {
+	var (
+		chars []lineChar
+		end   int
+	)
+	forEachCluster(line, func(cluster string, _ int) {
+		end += len(cluster)
+		if cluster[0] != '\x1b' {
+			chars = append(chars, lineChar{end, strings.TrimSpace(cluster) == ""})
+		}
+	})
+	return chars
+}
+
+// charsAbove returns how many characters of line are on the rows above
+// row when it's wrapped to width.
+func (m *Model) charsAbove(line string, row, width int) int {
+	line, _ = hideHyperlinks(line)
+	chars := lineChars(line)
+	n := sort.Search(len(chars), func(n int) bool {
+		return m.charRow(line, chars, n, width) >= row
+	})
+	// whitespace a row was broken at isn't shown on either row
+	for n < len(chars) && chars[n].space {
+		n++
+	}
+	return n
+}
+
+// rowOfChar returns which row of line the character following the first
+// n is on when it's wrapped to width.
+func (m *Model) rowOfChar(line string, n, width int) int {
+	line, _ = hideHyperlinks(line)
+	chars := lineChars(line)
+	if len(chars) == 0 {
+		return 0
+	}
+	return m.charRow(line, chars, min(n, len(chars)-1), width)
+}
 
-func (m *Model) SetDimensions(width, height int) { m.windowWidth, m.windowHeight = width, height }
+// charRow returns which row of line the nth of its characters, chars, is
+// on when it's wrapped to width.
+func (m *Model) charRow(line string, chars []lineChar, n, width int) int {
+	// wrap everything up to and including the character; it ends on the
+	// character's row. When breaking at word boundaries, that's only so
+	// once the rest of its word is included too.
+	if m.wrapStyle == WrapWords && !m.shouldHardwrap {
+		for n+1 < len(chars) && !chars[n].space && !chars[n+1].space {
+			n++
+		}
+	}
+	return strings.Count(m.wrap(line[:chars[n].end], width), "\n")
+}
+
+// viewSize returns the size of the area View renders into.
+func (m *Model) viewSize() (int, int) {
+	if m.renderWidth > 0 || m.renderHeight > 0 {
+		return m.renderWidth, m.renderHeight
+	}
+	return m.windowWidth, m.windowHeight
+}
 
 func (m *Model) Query() string {
 	return m.input.Value()
@@ -504,60 +2559,779 @@ func (m *Model) SetFocus(focus FocusArea) {
 	m.focus = focus
 	switch focus {
 	case FocusSearchBar:
-		m.input.Focus()
-		m.handleSearch()
+		m.bar().Focus()
+		if m.editingFind {
+			m.applyFind()
+		} else {
+			m.searchNow()
+		}
+		if m.freezeOnSearch && m.scrollPosition < 0 {
+			m.pauseTail()
+		}
 	default:
 		m.input.Blur()
+		m.findInput.Blur()
+		m.editingFind = false
+		if m.tailPaused {
+			m.tailPaused = false
+			m.scrollPosition = -1
+		}
+	}
+}
+
+// SetFreezeOnSearch sets whether the viewport stops following new lines
+// while the search bar is focused, so that a fast log doesn't churn behind
+// the query being typed. Lines are still written as usual, and following
+// resumes once the search bar is closed.
+func (m *Model) SetFreezeOnSearch(freeze bool) { m.freezeOnSearch = freeze }
+
+// pauseTail stops tailing, keeping the lines that were displayed where
+// they are, until the search bar is closed.
+func (m *Model) pauseTail() {
+	m.scrollPosition = max(0, m.firstDisplayedLine)
+	m.cursor = m.lastDisplayedLine
+	if m.reverse {
+		m.cursor = m.firstDisplayedLine
+	}
+	m.tailPaused = true
+}
+
+// Blur makes the model ignore key presses, for when another component of
+// the program has focus. This is separate from which of the model's own
+// areas is focused, as reported by [Model.Focus], which is kept for when
+// it's unblurred.
+func (m *Model) Blur() {
+	m.blurred = true
+	m.bar().Blur()
+}
+
+// Unblur makes the model handle key presses again after [Model.Blur]. The
+// returned command starts the search bar's cursor blinking, if it's being
+// typed into.
+func (m *Model) Unblur() tea.Cmd {
+	m.blurred = false
+	if m.focus == FocusSearchBar {
+		return m.bar().Focus()
+	}
+	return nil
+}
+
+// IsFocused reports whether the model handles key presses, which it does
+// unless [Model.Blur] was called.
+func (m *Model) IsFocused() bool { return !m.blurred }
+
+// SetQuitKeys sets the keys that quit the program when pressed in the log
+// pane, spelled like [tea.Key.String]. The default is "ctrl+c" and "esc";
+// embedders that use those keys for something else, like going back, can
+// set none.
+func (m *Model) SetQuitKeys(keys []string) { m.quitKeys = slices.Clone(keys) }
+
+// OpenSearch focuses the search bar with an empty query, as if "/" had
+// been pressed, remembering the current query in case the search is
+// cancelled.
+func (m *Model) OpenSearch() {
+	m.prevQuery = m.Query()
+	m.saveScroll(m.prevQuery)
+	m.input.SetValue("")
+	m.searchNow()
+	m.SetFocus(FocusSearchBar)
+}
+
+// TypeSearch adds text to the query or find in the search bar, as if it
+// were typed. Like typing, the search runs in the background: the returned
+// command delivers its results.
+func (m *Model) TypeSearch(text string) tea.Cmd {
+	if text == "" {
+		return nil
+	}
+	input := m.bar()
+	input.SetValue(input.Value() + text)
+	input.CursorEnd()
+	return m.handleBarChange()
+}
+
+// handleBarChange applies what's been typed into the search bar.
+func (m *Model) handleBarChange() tea.Cmd {
+	if m.editingFind {
+		m.applyFind()
+		return nil
+	}
+	return m.handleSearch()
+}
+
+// CloseSearch returns focus to the log pane from the search bar, as if
+// enter had been pressed if apply is set, or escape if not, restoring the
+// query or find from before [Model.OpenSearch] or [Model.OpenFind].
+func (m *Model) CloseSearch(apply bool) {
+	editingQuery := !m.editingFind
+	switch {
+	case !editingQuery && !apply:
+		m.SetFind(m.prevFind)
+	case !apply:
+		m.input.SetValue(m.prevQuery)
+		m.searchNow()
+	}
+	m.prevQuery, m.prevFind = "", ""
+	m.SetFocus(FocusLogPane)
+	if editingQuery {
+		m.recallScroll(m.Query())
 	}
 }
 
 func (m *Model) SetQuery(query string) {
+	m.saveScroll(m.Query())
 	m.input.SetValue(query)
-	m.handleSearch()
+	m.searchNow()
+	m.recallScroll(query)
 }
 
-func (m *Model) ScrollBy(lines int) {
-	lineView := m.lines
-	if m.queryRe != nil {
-		lineView = m.filtered
-	}
+// ScrollBy scrolls the viewport by the given number of lines. The returned
+// command emits a [ScrollMsg] if the viewport moved.
+func (m *Model) ScrollBy(lines int) tea.Cmd {
+	before := m.scrollPosition
+
 	// if tailing, first set scroll position to the bottom before adjusting it.
 	if m.scrollPosition < 0 {
 		m.scrollPosition = max(0, m.firstDisplayedLine)
 	}
 
 	// update scroll position
-	m.scrollPosition = clamp(0, len(lineView)-1, m.scrollPosition+lines)
+	target := m.scrollPosition + lines
+	m.scrollPosition = clamp(0, m.maxScroll(), target)
+	m.clampCursor()
+	return m.scrollCmd(before)
 }
 
-func (m *Model) ScrollTo(line int) {
-	lineView := m.lines
-	if m.queryRe != nil {
-		lineView = m.filtered
-	}
+// ScrollTo pins the given line to the top of the viewport, or resumes
+// tailing if line is negative. The returned command emits a [ScrollMsg] if
+// the viewport moved.
+func (m *Model) ScrollTo(line int) tea.Cmd {
+	before := m.scrollPosition
 	if line < 0 {
 		m.scrollPosition = -1
-	} else {
-		m.scrollPosition = clamp(0, len(lineView)-1, line)
+		return m.scrollCmd(before)
+	}
+	m.scrollPosition = clamp(0, m.maxScroll(), line)
+	m.clampCursor()
+	return m.scrollCmd(before)
+}
+
+// Edge is an edge of the view that scrolling can reach.
+type Edge int
+
+const (
+	// EdgeTop is the top of the view, where its first line is, or its
+	// last in reverse.
+	EdgeTop Edge = iota + 1
+	// EdgeBottom is the bottom of the view.
+	EdgeBottom
+)
+
+// EdgeMsg is emitted by scrolling operations when the viewport reaches the
+// top or bottom of the view, like to page in more of the log from
+// elsewhere. It's emitted once on reaching an edge, even if the viewport
+// was already there, and not again until the viewport has left it. Tailing
+// counts as being at the bottom, or at the top in reverse.
+type EdgeMsg struct{ Edge Edge }
+
+// edgeCmd returns a command emitting an [EdgeMsg] if the viewport is at an
+// edge it wasn't at when last checked, or nil otherwise.
+func (m *Model) edgeCmd() tea.Cmd {
+	edge := m.atEdge()
+	if edge == m.edge {
+		return nil
+	}
+	m.edge = edge
+	if edge == 0 {
+		return nil
+	}
+	return func() tea.Msg { return EdgeMsg{edge} }
+}
+
+// atEdge returns the edge of the view the viewport is at, if any.
+func (m *Model) atEdge() Edge {
+	switch pos := m.scrollPosition; {
+	case pos < 0 && m.reverse:
+		return EdgeTop
+	case pos < 0:
+		return EdgeBottom
+	case pos == 0:
+		return EdgeTop
+	// the bottom is at most a viewport's worth of lines from the end, so
+	// don't work out exactly where unless it's close
+	case pos >= m.viewLen()-max(1, m.bodyHeight) && pos >= m.bottomTop():
+		return EdgeBottom
+	}
+	return 0
+}
+
+// ScrollPosition returns the position of the line at the top of the
+// viewport, and whether it's tailing the log. Passing them back to
+// [Model.SetScrollPosition] restores the viewport.
+func (m *Model) ScrollPosition() (line int, tailing bool) {
+	if m.scrollPosition < 0 {
+		return m.firstDisplayedLine, true
+	}
+	return m.scrollPosition, false
+}
+
+// SetScrollPosition pins the line at the given position to the top of the
+// viewport, or resumes tailing if tailing is set, in which case line is
+// ignored. The returned command emits a [ScrollMsg] if the viewport moved.
+func (m *Model) SetScrollPosition(line int, tailing bool) tea.Cmd {
+	if tailing {
+		return m.ScrollTo(-1)
+	}
+	return m.ScrollTo(max(0, line))
+}
+
+// Follow resumes tailing the log, so that the viewport keeps up with new
+// lines as they're written.
+func (m *Model) Follow() tea.Cmd { return m.ScrollTo(-1) }
+
+// ScrollToBottom scrolls the viewport so the last line is at the bottom,
+// without tailing: new lines don't move it. In reverse, the last line is at
+// the top.
+func (m *Model) ScrollToBottom() tea.Cmd {
+	n := m.viewLen()
+	if n == 0 {
+		return nil
+	}
+	if m.reverse {
+		return m.ScrollTo(0)
+	}
+
+	before := m.scrollPosition
+	m.scrollPosition, m.cursor, m.rowOffset = m.bottomTop(), n-1, 0
+	return m.scrollCmd(before)
+}
+
+// bottomTop returns the position to scroll to so that the last line in the
+// view is at the bottom of the viewport.
+func (m *Model) bottomTop() int {
+	n := m.viewLen()
+	if n == 0 {
+		return 0
+	}
+	// lines are dedented as they will be at the bottom
+	dedent := m.indentFrom(max(0, n-m.bodyHeight), m.bodyHeight)
+	top, rows := n-1, m.rowsAt(n-1, dedent)
+	if !m.reverse && m.showBuffer() {
+		rows++
+	}
+	for top > 0 && rows+m.rowsAt(top-1, dedent) <= m.bodyHeight {
+		top--
+		rows += m.rowsAt(top, dedent)
+	}
+	return top
+}
+
+// SetOverscroll sets whether the viewport can be scrolled past the end of
+// the view, until the last line is at the top with blank space below, like
+// in less. Otherwise, scrolling stops with the last line at the bottom. The
+// default is to allow overscroll.
+func (m *Model) SetOverscroll(overscroll bool) { m.noOverscroll = !overscroll }
+
+// maxScroll returns the furthest position the viewport can be scrolled to.
+func (m *Model) maxScroll() int {
+	if m.noOverscroll {
+		return m.bottomTop()
+	}
+	return m.viewLen() - 1
+}
+
+// SetFollowOnBottom sets whether `G` and `end` resume tailing the log, or
+// just scroll to the bottom of it like [Model.ScrollToBottom]. Either way,
+// `F` resumes tailing. The default is to resume tailing.
+func (m *Model) SetFollowOnBottom(follow bool) { m.stayOnBottom = !follow }
+
+// goToBottom scrolls to the bottom of the log, tailing it unless
+// SetFollowOnBottom says otherwise.
+func (m *Model) goToBottom() tea.Cmd {
+	if m.stayOnBottom {
+		return m.ScrollToBottom()
+	}
+	return m.Follow()
+}
+
+// ScrollToLast scrolls the viewport to show the last n lines of the log,
+// without tailing it.
+func (m *Model) ScrollToLast(n int) tea.Cmd {
+	if m.reverse {
+		return m.ScrollTo(0)
+	}
+	return m.ScrollTo(max(0, m.viewLen()-n))
+}
+
+// ScrollMsg is emitted by scrolling operations when the viewport moves, so
+// that other components can follow along.
+type ScrollMsg struct {
+	// Top is the position of the line at the top of the viewport.
+	Top int
+	// Tailing is true if the viewport is following new lines.
+	Tailing bool
+}
+
+// scrollCmd returns a command emitting a [ScrollMsg] if the scroll position
+// has changed from before, and an [EdgeMsg] if it has reached an edge, or
+// nil if neither. Everything that moves the viewport reports it with this.
+func (m *Model) scrollCmd(before int) tea.Cmd {
+	edge := m.edgeCmd()
+	if m.scrollPosition == before {
+		return edge
+	}
+	msg := ScrollMsg{Top: m.scrollPosition, Tailing: m.scrollPosition < 0}
+	if msg.Tailing {
+		msg.Top = m.firstDisplayedLine
+	}
+	return tea.Batch(func() tea.Msg { return msg }, edge)
+}
+
+// CurrentLine returns the index of the current line, or -1 if there are no
+// lines to display. While tailing, the current line is the last line.
+func (m *Model) CurrentLine() int {
+	n := m.viewLen()
+	if n == 0 {
+		return -1
+	}
+	if m.scrollPosition < 0 && m.reverse {
+		return m.viewIndex(0)
+	}
+	if m.scrollPosition < 0 {
+		return m.viewIndex(n - 1)
+	}
+	return m.viewIndex(clamp(0, n-1, m.cursor))
+}
+
+// MoveCursor moves the current line by n lines, scrolling the viewport if
+// the current line would leave it. The returned command emits a
+// [ScrollMsg] if the viewport moved.
+func (m *Model) MoveCursor(n int) tea.Cmd {
+	before := m.scrollPosition
+	if m.scrollPosition < 0 {
+		m.scrollPosition = max(0, m.firstDisplayedLine)
+		m.cursor = m.lastDisplayedLine
+		if m.reverse {
+			m.cursor = m.firstDisplayedLine
+		}
+	}
+	m.cursor = clamp(0, m.viewLen()-1, m.cursor+n)
+	m.revealCursor()
+	return m.scrollCmd(before)
+}
+
+// revealCursor scrolls the viewport so the cursor is visible, keeping
+// m.scrollOff lines of context above and below it where possible.
+func (m *Model) revealCursor() {
+	var (
+		visible = m.lastDisplayedLine - m.firstDisplayedLine + 1
+		off     = clamp(0, max(0, (visible-1)/2), m.scrollOff)
+		shift   = 0
+	)
+	if top := m.scrollPosition + off; m.cursor < top {
+		shift = max(-m.scrollPosition, m.cursor-top)
+	} else if bottom := m.lastDisplayedLine - off; m.cursor > bottom {
+		// don't scroll past the end of the log just for context
+		shift = max(m.cursor-m.lastDisplayedLine, min(m.cursor-bottom, m.viewLen()-1-m.lastDisplayedLine))
 	}
+
+	// until the next render, assume the viewport still fits as many lines
+	m.scrollPosition = clamp(0, max(0, m.maxScroll()), m.scrollPosition+shift)
+	m.firstDisplayedLine += shift
+	m.lastDisplayedLine += shift
+}
+
+// SetScrollOff sets the number of lines of context kept visible above and
+// below the current line while navigating, like vim's `scrolloff`.
+func (m *Model) SetScrollOff(n int) { m.scrollOff = max(0, n) }
+
+// moveCursorTo moves the current line to position pos in the current view.
+func (m *Model) moveCursorTo(pos int) tea.Cmd {
+	return m.MoveCursor(pos - m.cursorPosition())
+}
+
+// clampCursor keeps the cursor within the lines that were last displayed,
+// after the viewport has been scrolled.
+func (m *Model) clampCursor() {
+	height := max(0, m.lastDisplayedLine-m.firstDisplayedLine)
+	m.cursor = clamp(m.scrollPosition, m.scrollPosition+height, m.cursor)
 }
 
 func (m *Model) ShowStatusbar(show bool) { m.shouldShowStatusbar = show }
 
-func (m *Model) SetWrapMode(hardwrap bool) { m.shouldHardwrap = hardwrap }
-func (m *Model) ToggleWrapMode()           { m.shouldHardwrap = !m.shouldHardwrap }
+// SetShowPartialLines sets whether an incomplete last line is displayed as
+// it's written, or held back until its newline arrives.
+func (m *Model) SetShowPartialLines(show bool) { m.shouldShowPartialLines = show }
+
+// SetCountPartialLine sets whether the incomplete last line, while it's
+// shown, counts towards the number of lines in the statusbar, which it does
+// by default. Not counting it keeps the count steady while a line is being
+// written bit by bit. Match counts never include it.
+func (m *Model) SetCountPartialLine(count bool) { m.noCountPartial = !count }
+
+// showBuffer reports whether the incomplete last line should be displayed.
+//
+// While filtering, it's only displayed if it passes the filter so far.
+// Otherwise, a line that won't pass would flash up at the bottom of the
+// view while it's written, only to vanish once it's complete.
+func (m *Model) showBuffer() bool {
+	// a lone "\r" is probably the first half of a CRLF ending an empty
+	// line, which would render as a blank row below the last line
+	if m.buffer == "" || m.buffer == "\r" || !m.shouldShowPartialLines {
+		return false
+	}
+	if m.filtering() {
+		return m.lineMatcher(m.queryRe)(strings.TrimSuffix(m.buffer, "\r"), m.bufferStream)
+	}
+	return true
+}
+
+// SetColumns displays only the given zero-indexed fields of each line, split
+// on delimiter, like `cut -f`. An empty delimiter splits on runs of
+// whitespace, like awk. Passing nil fields displays whole lines again.
+func (m *Model) SetColumns(delimiter string, fields []int) {
+	m.columnDelimiter, m.columnFields = delimiter, fields
+}
+
+// SetRelativeLineNumbers shows a gutter with each line's distance from the
+// current line, like vim's `relativenumber`. The current line shows its
+// absolute line number.
+func (m *Model) SetRelativeLineNumbers(relative bool) { m.relativeLineNumbers = relative }
+
+// SetReverse displays the log newest-first. While tailing, the view is
+// pinned to the top of the log.
+func (m *Model) SetReverse(reverse bool) {
+	if m.reverse != reverse && m.scrollPosition >= 0 {
+		m.scrollPosition = max(0, m.viewLen()-1-m.scrollPosition)
+		m.cursor = max(0, m.viewLen()-1-m.cursor)
+	}
+	m.reverse = reverse
+}
+
+// SetSearchPrompt sets the prompt shown before the query in the search bar,
+// like "grep> ". The default is "/".
+func (m *Model) SetSearchPrompt(prompt string) { m.input.Prompt = prompt }
+
+// SetAnchor sets whether the query must match at the start and/or end of
+// the line, as if it were wrapped in `^(?:...)` or `(?:...)$`.
+func (m *Model) SetAnchor(start, end bool) {
+	m.anchorStart, m.anchorEnd = start, end
+	m.searchNow()
+}
+
+// SetQuickFilter stashes pattern in slot, from 1 to 9, to be applied by
+// pressing the matching function key, F1 to F9. An empty pattern clears the
+// slot.
+func (m *Model) SetQuickFilter(slot int, pattern string) {
+	if slot < 1 || slot > len(m.quickFilters) {
+		return
+	}
+	m.quickFilters[slot-1] = pattern
+}
+
+// QuickFilters returns the stashed patterns by slot, for saving them to be
+// restored with [Model.SetQuickFilter] later.
+func (m *Model) QuickFilters() map[int]string {
+	filters := make(map[int]string)
+	for i, pattern := range m.quickFilters {
+		if pattern != "" {
+			filters[i+1] = pattern
+		}
+	}
+	return filters
+}
+
+// applyQuickFilter searches for the pattern stashed in slot, as if it had
+// been typed into the search bar.
+func (m *Model) applyQuickFilter(slot int) tea.Cmd {
+	pattern := m.quickFilters[slot-1]
+	if pattern == "" {
+		return nil
+	}
+	m.prevQuery = m.Query()
+	m.saveScroll(m.prevQuery)
+	m.input.SetValue(pattern)
+	m.input.CursorEnd()
+	cmd := m.handleSearch()
+	m.recallScroll(pattern)
+	return cmd
+}
+
+// SetStreamFilter narrows the view to lines written to the given streams,
+// in addition to any query. Calling it with no streams shows every stream.
+func (m *Model) SetStreamFilter(streams ...Stream) {
+	m.streamFilter = nil
+	if len(streams) > 0 {
+		m.streamFilter = slices.Clone(streams)
+	}
+	m.searchNow()
+}
+
+// SetFilterRule narrows the view to lines that match include, if it isn't
+// nil, and don't match exclude, if it isn't nil, in addition to any query.
+// Matches of include are highlighted when there's no query or find to
+// highlight instead; exclude is never highlighted, since the lines it
+// matches aren't shown.
+func (m *Model) SetFilterRule(include, exclude *regexp.Regexp) {
+	m.filterInclude, m.filterExclude = include, exclude
+	m.searchNow()
+}
+
+// SetSearchScope sets which lines are searched. [SearchScopeViewport] only
+// highlights matches in the lines on screen, without filtering, which is
+// much cheaper for huge logs.
+func (m *Model) SetSearchScope(scope SearchScope) {
+	m.searchScope = scope
+	m.searchNow()
+}
+
+// SetMatchGutter shows a column alongside the log marking the lines that
+// match the query, which is handy for spotting matches when they're only
+// highlighted rather than filtered.
+func (m *Model) SetMatchGutter(show bool) { m.shouldShowMatchGutter = show }
+
+// SetRuler shows a row numbering the columns of the log, every ten
+// columns, pinned to the top of the viewport. It's handy for lining up the
+// fields of fixed-width logs.
+func (m *Model) SetRuler(show bool) { m.shouldShowRuler = show }
+
+// SetMatchMinimap shows a column at the right edge of the log marking where
+// in the log the current filter has matches.
+func (m *Model) SetMatchMinimap(show bool) { m.shouldShowMinimap = show }
+
+// SetShowInvisibles renders trailing spaces as `·`, tabs as `→`, and other
+// control characters as their Unicode control pictures, like `␀`. The
+// stored lines are left unchanged.
+func (m *Model) SetShowInvisibles(show bool) { m.shouldShowInvisibles = show }
+
+// SetColorizer sets the [Colorizer] used to style lines as they're
+// rendered, like [LevelColorizer] or [JSONColorizer]. Passing nil renders
+// lines as they were written.
+func (m *Model) SetColorizer(colorizer Colorizer) { m.colorizer = colorizer }
+
+// SetStickyHeader pins the first n lines of the log to the top of the
+// viewport, like a frozen header row in a spreadsheet. They're left out of
+// the lines that scroll beneath them, and of the line count in the
+// statusbar.
+func (m *Model) SetStickyHeader(n int) { m.stickyHeader = max(0, n) }
+
+// SetWrapStyle chooses how lines are broken when soft wrapping.
+func (m *Model) SetWrapStyle(style WrapStyle) { m.wrapStyle = style }
+
+// SetHangingIndent sets whether soft-wrapped continuation rows are indented
+// to match the leading whitespace of their line, keeping indented
+// structures aligned.
+func (m *Model) SetHangingIndent(indent bool) { m.hangingIndent = indent }
+
+// SetWrapMode sets whether long lines are truncated (hard wrapped) or
+// continued onto more rows (soft wrapped). The returned command emits a
+// [WrapModeChangedMsg] if the mode changed.
+func (m *Model) SetWrapMode(hardwrap bool) tea.Cmd {
+	if m.shouldHardwrap == hardwrap {
+		return nil
+	}
+	m.shouldHardwrap = hardwrap
+	return func() tea.Msg { return WrapModeChangedMsg{HardWrap: hardwrap} }
+}
+
+func (m *Model) ToggleWrapMode() tea.Cmd { return m.SetWrapMode(!m.shouldHardwrap) }
+
+// IsHardWrap reports whether long lines are truncated rather than soft
+// wrapped.
+func (m *Model) IsHardWrap() bool { return m.shouldHardwrap }
+
+// WrapModeChangedMsg is emitted when the wrap mode changes, so that other
+// components can reflect it.
+type WrapModeChangedMsg struct {
+	// HardWrap is true if long lines are now truncated.
+	HardWrap bool
+}
+
+// pageDistance is how far pgup/pgdown scroll: a page, less a few lines of
+// overlap for context, but always at least one line.
+func (m *Model) pageDistance() int {
+	_, height := m.viewSize()
+	return max(1, m.logHeight(height)-3)
+}
+
+func (m *Model) halfPageDistance() int {
+	_, height := m.viewSize()
+	return max(1, m.logHeight(height)/2)
+}
 
 func (m *Model) logHeight(height int) int {
-	if !m.shouldShowStatusbar {
-		return height
+	// the statusbar is skipped if the window is too short; see Render
+	if !m.shouldShowStatusbar || height < 2 {
+		return max(height, 0)
+	}
+	return height - 1
+}
+
+// LineCount returns the number of complete lines written to the log. An
+// incomplete last line, which hasn't had its newline written yet, is not
+// counted.
+func (m *Model) LineCount() int { return m.lineCount() }
+
+// FilteredCount returns the number of complete lines that currently pass
+// the filter. If no filter is active, it's the same as [Model.LineCount].
+// Lines shown only as context, and lines hidden by folds, don't affect it.
+func (m *Model) FilteredCount() int {
+	if !m.filtering() {
+		return m.lineCount()
 	}
-	return max(height-1, 0)
+	return len(m.filtered) - len(m.contextLines)
+}
+
+// FilteredLines returns the raw, unhighlighted lines that currently pass the
+// filter, like [Model.FilteredIndices].
+func (m *Model) FilteredLines() []string {
+	indices := m.FilteredIndices()
+	result := make([]string, len(indices))
+	for i, index := range indices {
+		result[i] = m.line(index)
+	}
+	return result
+}
+
+// FilteredIndices returns the indices of the lines that currently pass the
+// filter, in order. If no filter is active, every complete line passes.
+// Lines shown only as context aren't included, and lines hidden by folds
+// are.
+func (m *Model) FilteredIndices() []int {
+	if !m.filtering() {
+		result := make([]int, m.lineCount())
+		for i := range result {
+			result[i] = i
+		}
+		return result
+	}
+	result := make([]int, 0, m.FilteredCount())
+	for _, i := range m.filtered {
+		if !m.isContext(i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// filtering reports whether the view is narrowed to lines matching the
+// query. In [SearchScopeViewport], matches are only highlighted.
+func (m *Model) filtering() bool {
+	return (m.queryRe != nil && m.searchScope == SearchScopeAll) ||
+		m.streamFilter != nil ||
+		m.filterInclude != nil || m.filterExclude != nil || m.fieldRe != nil ||
+		(m.extractRe != nil && m.hideUnextracted)
+}
+
+// viewLen returns the number of complete lines in the current view: every
+// line, or only those passing the filter, less those pinned as the sticky
+// header. A fold counts as one line.
+func (m *Model) viewLen() int {
+	return m.filterLen() - m.headerLen()
+}
+
+// filterLen returns the number of complete lines passing the filter,
+// including any in the sticky header. A fold counts as one line.
+func (m *Model) filterLen() int {
+	if m.filtering() {
+		return len(m.filtered)
+	}
+	return m.lineCount() - m.foldedLines()
+}
+
+// headerLen returns how many of the lines passing the filter are pinned as
+// the sticky header, which are rendered above the view rather than in it.
+func (m *Model) headerLen() int {
+	n := min(m.stickyHeader, m.lineCount())
+	if n == 0 {
+		return 0
+	}
+	if m.filtering() {
+		k, _ := slices.BinarySearch(m.filtered, n)
+		return k
+	}
+	k := 0
+	for k < m.filterLen() && m.unfoldIndex(k) < n {
+		k++
+	}
+	return k
+}
+
+// viewIndex maps a position in the current view to a line index.
+func (m *Model) viewIndex(i int) int {
+	if m.reverse {
+		i = m.viewLen() - 1 - i
+	}
+	return m.filterIndex(m.headerLen() + i)
+}
+
+// filterIndex maps a position among the lines passing the filter, in the
+// order they were written, to a line index.
+func (m *Model) filterIndex(i int) int {
+	if m.filtering() {
+		return m.filtered[i]
+	}
+	return m.unfoldIndex(i)
+}
+
+// viewPosition is the inverse of viewIndex: it maps a line index to its
+// position in the current view. Lines that aren't in the view map to the
+// position of the next line that is; folded lines map to their fold.
+func (m *Model) viewPosition(line int) int {
+	var pos int
+	if m.filtering() {
+		pos, _ = slices.BinarySearch(m.filtered, line)
+	} else {
+		pos = line
+		for _, f := range m.folds {
+			if f.start >= line {
+				break
+			}
+			pos -= min(line, f.end) - f.start
+		}
+	}
+	pos -= m.headerLen()
+	if m.reverse {
+		pos = m.viewLen() - 1 - pos
+	}
+	return clamp(0, max(0, m.viewLen()-1), pos)
+}
+
+// keepViewport returns a function that, once the view has changed, scrolls
+// back to the lines that were at the top of the viewport and under the
+// cursor beforehand.
+func (m *Model) keepViewport() func() {
+	n := m.viewLen()
+	if m.scrollPosition < 0 || n == 0 {
+		return func() {}
+	}
+	top := m.viewIndex(clamp(0, n-1, m.scrollPosition))
+	current := m.viewIndex(clamp(0, n-1, m.cursor))
+	return func() {
+		m.scrollPosition, m.cursor = m.viewPosition(top), m.viewPosition(current)
+	}
+}
+
+// Line returns the line at index, as it was written. The incomplete last
+// line, if any, comes after the complete ones, at index [Model.LineCount].
+// ok is false if there's no line at index.
+func (m *Model) Line(index int) (line string, ok bool) {
+	switch n := m.lineCount(); {
+	case index >= 0 && index < n:
+		return m.line(index), true
+	case index == n && m.buffer != "":
+		return m.buffer, true
+	}
+	return "", false
 }
 
 func (m *Model) content() []string {
-	lines := m.lines
+	lines := make([]string, m.lineCount(), m.lineCount()+1)
+	for i := range lines {
+		lines[i] = m.line(i)
+	}
 	if m.buffer != "" {
-		lines = append(m.lines, m.buffer)
+		lines = append(lines, m.buffer)
 	}
 	return lines
 }
@@ -570,3 +3344,43 @@ const (
 	FocusLogPane
 	FocusHelp
 )
+
+type SearchScope int
+
+const (
+	// SearchScopeAll filters the whole log down to matching lines.
+	SearchScopeAll SearchScope = iota
+	// SearchScopeViewport highlights matches within the viewport only.
+	SearchScopeViewport
+)
+
+// PageAlignment is where a partial page of lines is shown in the viewport.
+type PageAlignment int
+
+const (
+	// PageAlignTop shows the lines at the top, with blank space below.
+	PageAlignTop PageAlignment = iota
+	// PageAlignBottom shows the lines at the bottom, like a terminal.
+	PageAlignBottom
+)
+
+type WrapStyle int
+
+const (
+	// WrapCharacters fills each row to the full width, breaking wherever
+	// the row runs out.
+	WrapCharacters WrapStyle = iota
+	// WrapWords breaks rows at word boundaries, only breaking within a
+	// word if it's too long to fit on a row by itself.
+	WrapWords
+)
+
+// Stream identifies where written content came from, like a subprocess's
+// stdout or stderr.
+type Stream int
+
+const (
+	// StreamStdout is the stream used by Write, WriteBytes, and Writer.
+	StreamStdout Stream = iota
+	StreamStderr
+)
@@ -0,0 +1,1181 @@
+package logview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screen renders m at the given size as plain text, split into rows with
+// trailing spaces trimmed.
+func screen(m *Model, width, height int) []string {
+	rows := strings.Split(m.RenderAt(width, height), "\n")
+	for i, row := range rows {
+		rows[i] = strings.TrimRight(row, " ")
+	}
+	return rows
+}
+
+// keyTypes maps key names, like "enter" and "ctrl+d", to their types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for k := tea.KeyType(-256); k <= 256; k++ {
+		if name := k.String(); len([]rune(name)) > 1 {
+			types[name] = k
+		}
+	}
+	return types
+}()
+
+// press handles a key press for each of keys, named like [tea.Key.String],
+// returning the command from the last.
+func press(m *Model, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if k, ok := keyTypes[key]; ok {
+			msg = tea.KeyMsg{Type: k}
+		}
+		cmd = m.handleKey(msg)
+	}
+	return cmd
+}
+
+func assertScreen(t *testing.T, m *Model, width, height int, want ...string) {
+	t.Helper()
+	if got := screen(m, width, height); !slices.Equal(got, want) {
+		t.Errorf("screen =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestStickyHeader(t *testing.T) {
+	m := New()
+	m.SetStickyHeader(1)
+	m.Write("HEADER\na\nb\nc\n")
+
+	// tailing
+	assertScreen(t, m, 10, 5, "HEADER", "a", "b", "c", "")
+
+	// scrolled to the top
+	m.ScrollTo(0)
+	assertScreen(t, m, 10, 5, "HEADER", "a", "b", "c", "1 of 3")
+
+	// scrolling up can't reach the header
+	m.ScrollBy(-5)
+	assertScreen(t, m, 10, 5, "HEADER", "a", "b", "c", "1 of 3")
+	m.ScrollBy(1)
+	assertScreen(t, m, 10, 5, "HEADER", "b", "c", "", "2 of 3")
+}
+
+func TestStickyHeaderFiltered(t *testing.T) {
+	m := New()
+	m.SetStickyHeader(1)
+	m.Write("level msg\nerror a\ninfo b\nerror c\n")
+	m.SetFilterRule(regexp.MustCompile("e"), nil)
+	m.ScrollTo(0)
+	// the header matches too, but it's only shown pinned
+	assertScreen(t, m, 12, 5, "level msg", "error a", "error c", "", "1 of 2")
+}
+
+func TestStickyHeaderOnly(t *testing.T) {
+	m := New()
+	m.SetStickyHeader(2)
+	m.Write("a\nb\n")
+	rows := screen(m, 10, 5)
+	if rows[0] != "a" || rows[1] != "b" || !strings.Contains(strings.Join(rows[2:], "\n"), "(empty)") {
+		t.Errorf("screen = %q, want the header above (empty)", rows)
+	}
+}
+
+func TestWriteBytes(t *testing.T) {
+	m := New()
+	m.WriteBytes([]byte("a\r\nb"))
+	m.WriteBytes([]byte("c\n\nd"))
+	if got, want := m.String(), "a\nbc\n\nd"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkWriteBytes(b *testing.B) {
+	chunk := []byte(strings.Repeat("2024-01-02 15:04:05 INFO request handled in 12ms\n", 64))
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(16 * len(chunk)))
+		for i := 0; i < b.N; i++ {
+			m := New()
+			for range 16 {
+				m.WriteBytes(chunk)
+			}
+		}
+	})
+	// the same content written as strings, for comparison
+	b.Run("string", func(b *testing.B) {
+		s := string(chunk)
+		b.ReportAllocs()
+		b.SetBytes(int64(16 * len(chunk)))
+		for i := 0; i < b.N; i++ {
+			m := New()
+			for range 16 {
+				m.Write(s)
+			}
+		}
+	})
+}
+
+func TestWriteNewlines(t *testing.T) {
+	tests := []struct {
+		writes []string
+		want   []string
+	}{
+		{[]string{"\n"}, []string{""}},
+		{[]string{"\n", "\n"}, []string{"", ""}},
+		{[]string{"ab", "\n", "\n"}, []string{"ab", ""}},
+		{[]string{"ab\n\n"}, []string{"ab", ""}},
+		{[]string{"a", "b", "\n"}, []string{"ab"}},
+		{[]string{"a\r", "\n"}, []string{"a"}},
+		{[]string{"a\nb"}, []string{"a"}},
+		{[]string{"a\nb", "\n"}, []string{"a", "b"}},
+		{[]string{"", "a", "", "\n"}, []string{"a"}},
+	}
+	writers := map[string]func(m *Model, s string){
+		"Write":      (*Model).Write,
+		"WriteBytes": func(m *Model, s string) { m.WriteBytes([]byte(s)) },
+		"WriteBatch": func(m *Model, s string) { m.writeBatch([]string{s}) },
+	}
+	for name, write := range writers {
+		for _, tt := range tests {
+			m := New()
+			for _, s := range tt.writes {
+				write(m, s)
+			}
+			var got []string
+			for i := range m.lineCount() {
+				got = append(got, m.line(i))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s%q: lines = %q, want %q", name, tt.writes, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestFilteredIndices(t *testing.T) {
+	m := New()
+	m.Write("a\nerr b\nc\nd\nerr e\nf\n")
+	m.Fold(2, 3)
+	if got, want := m.FilteredIndices(), []int{0, 1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("unfiltered FilteredIndices() = %v, want %v", got, want)
+	}
+
+	m.SetFilterRule(regexp.MustCompile("err"), nil)
+	m.SetFilterContext(1, 0)
+	if got, want := m.FilteredIndices(), []int{1, 4}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+	if got, want := m.FilteredLines(), []string{"err b", "err e"}; !slices.Equal(got, want) {
+		t.Errorf("FilteredLines() = %q, want %q", got, want)
+	}
+	if got := m.FilteredCount(); got != 2 {
+		t.Errorf("FilteredCount() = %d, want 2", got)
+	}
+}
+
+func TestResizeKeepsTopRow(t *testing.T) {
+	long := "0123456789 abcdefghi 0123456789 abcdefghi 012345"
+	tests := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"soft wrap", func(m *Model) {}},
+		{"word wrap", func(m *Model) { m.SetWrapStyle(WrapWords) }},
+		{"hard wrap", func(m *Model) { m.SetWrapMode(true); m.SetHexMode(true) }},
+	}
+	for _, tt := range tests {
+		m := New(WithoutStatusbar, WithStartAtHead)
+		tt.setup(m)
+		m.Write("first\n" + long + "\nlast\n")
+		m.Update(tea.WindowSizeMsg{Width: 20, Height: 2})
+		screen(m, 20, 2)
+
+		// scroll the long line partly out of view
+		m.scrollPosition, m.rowOffset, m.rowOffsetLine = 1, 1, 1
+		want := screen(m, 20, 2)
+
+		for _, width := range []int{13, 7, 20} {
+			m.Update(tea.WindowSizeMsg{Width: width, Height: 2})
+			screen(m, width, 2)
+		}
+		if got := screen(m, 20, 2); !slices.Equal(got, want) || m.firstDisplayedLine != 1 {
+			t.Errorf("%s: after resizing back, screen = %q from line %d, want %q from line 1", tt.name, got, m.firstDisplayedLine, want)
+		}
+
+		// resizing back without rendering in between
+		m.Update(tea.WindowSizeMsg{Width: 13, Height: 2})
+		m.Update(tea.WindowSizeMsg{Width: 20, Height: 2})
+		if got := screen(m, 20, 2); !slices.Equal(got, want) {
+			t.Errorf("%s: after resizing back unrendered, screen = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestReplaceLine(t *testing.T) {
+	m := New()
+	m.Write("a\nerr b\nc\n")
+	m.SetFilterRule(regexp.MustCompile("err"), nil)
+
+	m.ReplaceLine(0, "err a\n")
+	m.ReplaceLine(1, "b")
+	m.ReplaceLine(3, "out of range")
+	if got, want := m.FilteredLines(), []string{"err a"}; !slices.Equal(got, want) {
+		t.Errorf("FilteredLines() = %q, want %q", got, want)
+	}
+	if got, want := m.String(), "err a\nb\nc"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestReplaceLineShared(t *testing.T) {
+	m := New()
+	m.Write("a\nb\n")
+
+	// with nothing reading them elsewhere, lines are replaced in place
+	lines := m.lines
+	m.ReplaceLine(0, "A")
+	if &m.lines[0] != &lines[0] {
+		t.Error("ReplaceLine copied lines that weren't shared")
+	}
+
+	// a background search keeps seeing the lines as they were
+	snapshot := m.sharedSnapshot()
+	m.ReplaceLine(1, "B")
+	if got := snapshot.line(1); got != "b" {
+		t.Errorf("shared snapshot line 1 = %q, want %q", got, "b")
+	}
+	if got := m.line(1); got != "B" {
+		t.Errorf("line 1 = %q, want %q", got, "B")
+	}
+}
+
+func TestDedent(t *testing.T) {
+	m := New(WithStartAtHead, WithoutStatusbar)
+	m.SetDedent(true)
+	m.SetStickyHeader(1)
+	m.Write("  header\n    a\n      b\n      c\n")
+
+	// the header keeps its indent, and doesn't limit the body's
+	assertScreen(t, m, 20, 4, "  header", "a", "  b", "  c")
+
+	// the ruler counts the columns trimmed from the body
+	m.SetRuler(true)
+	assertScreen(t, m, 20, 5, "······10········20··", "  header", "a", "  b", "  c")
+
+	// scrolled past the least indented line, there's more to trim
+	m.SetRuler(false)
+	m.ScrollTo(1)
+	assertScreen(t, m, 20, 4, "  header", "b", "c", "")
+}
+
+// msgs runs cmd, and any commands it batches, returning their messages.
+func msgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var result []tea.Msg
+		for _, cmd := range batch {
+			result = append(result, msgs(cmd)...)
+		}
+		return result
+	}
+	return []tea.Msg{msg}
+}
+
+// edges returns the edges reported by cmd's messages.
+func edges(cmd tea.Cmd) []Edge {
+	var result []Edge
+	for _, msg := range msgs(cmd) {
+		if msg, ok := msg.(EdgeMsg); ok {
+			result = append(result, msg.Edge)
+		}
+	}
+	return result
+}
+
+func TestEdgeMsg(t *testing.T) {
+	m := New()
+	m.Write(strings.Repeat("line\n", 10))
+	m.SetFollowOnBottom(false)
+	m.RenderAt(10, 5)
+
+	tests := []struct {
+		name   string
+		scroll func() tea.Cmd
+		want   []Edge
+	}{
+		{"ScrollTo(3)", func() tea.Cmd { return m.ScrollTo(3) }, nil},
+		{"ScrollBy(-5)", func() tea.Cmd { return m.ScrollBy(-5) }, []Edge{EdgeTop}},
+		{"ScrollBy(-1) at the top", func() tea.Cmd { return m.ScrollBy(-1) }, nil},
+		{"ScrollBy(1)", func() tea.Cmd { return m.ScrollBy(1) }, nil},
+		{"ScrollBy(-1) back to the top", func() tea.Cmd { return m.ScrollBy(-1) }, []Edge{EdgeTop}},
+		{"G", func() tea.Cmd { return m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}) }, []Edge{EdgeBottom}},
+		{"Follow at the bottom", m.Follow, nil},
+		{"ScrollToLast(10)", func() tea.Cmd { return m.ScrollToLast(10) }, []Edge{EdgeTop}},
+		{"CenterOn(9)", func() tea.Cmd { return m.CenterOn(9) }, []Edge{EdgeBottom}},
+		{"gg", func() tea.Cmd { return press(m, "g", "g") }, []Edge{EdgeTop}},
+		{"] to the last match", func() tea.Cmd { m.SetFind("line"); return press(m, "]") }, []Edge{EdgeBottom}},
+	}
+	for _, tt := range tests {
+		m.RenderAt(10, 5)
+		if got := edges(tt.scroll()); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: edges = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTinyViewports(t *testing.T) {
+	setups := map[string]func(m *Model){
+		"plain":         func(m *Model) {},
+		"sticky header": func(m *Model) { m.SetStickyHeader(1) },
+		"scrolled":      func(m *Model) { m.ScrollTo(2) },
+		"filtered":      func(m *Model) { m.SetFilterRule(regexp.MustCompile("o"), nil) },
+		"no statusbar":  func(m *Model) { m.ShowStatusbar(false) },
+	}
+	for name, setup := range setups {
+		for width := range 4 {
+			for height := range 4 {
+				m := New()
+				m.Write("one\ntwo\nthree wraps\nfour\nfi")
+				setup(m)
+				view := m.RenderAt(width, height)
+				if width == 0 || height == 0 {
+					if view != "" {
+						t.Errorf("%s at %dx%d: view = %q, want nothing", name, width, height, view)
+					}
+					continue
+				}
+				rows := strings.Split(view, "\n")
+				if len(rows) > height {
+					t.Errorf("%s at %dx%d: %d rows", name, width, height, len(rows))
+				}
+				for _, row := range rows {
+					if w := lipgloss.Width(row); w > width {
+						t.Errorf("%s at %dx%d: row %q is %d wide", name, width, height, row, w)
+					}
+				}
+
+				m.SetDimensions(width, height)
+				if m.pageDistance() < 1 || m.halfPageDistance() < 1 {
+					t.Errorf("%s at %dx%d: page distances %d and %d", name, width, height, m.pageDistance(), m.halfPageDistance())
+				}
+			}
+		}
+	}
+}
+
+func TestCountPrefix(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.Write(strings.Repeat("line\n", 30))
+	m.RenderAt(10, 6)
+
+	tests := []struct {
+		keys []string
+		want int
+	}{
+		{[]string{"3", "j"}, 3},
+		{[]string{"j"}, 4},
+		{[]string{"1", "2", "j"}, 16},
+		{[]string{"2", "k"}, 14},
+		{[]string{"5", "G"}, 4},
+		{[]string{"2", "0", "g", "g"}, 19},
+		{[]string{"g", "g"}, 0},
+		// a leading 0 isn't a count
+		{[]string{"0", "j"}, 1},
+		{[]string{"1", "0", "j"}, 11},
+	}
+	for _, tt := range tests {
+		press(m, tt.keys...)
+		if got := m.CurrentLine(); got != tt.want {
+			t.Errorf("after %q, current line = %d, want %d", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestToggleStatusbar(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.Write("a\nb\nc\nd\n")
+	assertScreen(t, m, 10, 3, "a", "b", "1 of 4")
+
+	press(m, "s")
+	assertScreen(t, m, 10, 3, "a", "b", "c")
+
+	press(m, "s")
+	assertScreen(t, m, 10, 3, "a", "b", "1 of 4")
+}
+
+func TestHyperlinkWrap(t *testing.T) {
+	const (
+		open  = "\x1b]8;;http://x\x1b\\"
+		close = "\x1b]8;;\x1b\\"
+	)
+	m := New(WithoutStatusbar)
+	m.Write(open + "abcdefgh" + close + "ij\n")
+
+	// the link is closed at the end of each row and reopened on the next
+	want := open + "abcd" + close + "\n" + open + "efgh" + close + "\nij"
+	if got := m.RenderLog(4, 3); got != want {
+		t.Errorf("RenderLog = %q, want %q", got, want)
+	}
+
+	// the link's URI doesn't count towards the width
+	want = open + "abcdefgh" + close + "ij"
+	if got := m.RenderLog(10, 1); got != want {
+		t.Errorf("RenderLog = %q, want %q", got, want)
+	}
+}
+
+// scrolls returns the ScrollMsgs in cmd's messages.
+func scrolls(cmd tea.Cmd) []ScrollMsg {
+	var result []ScrollMsg
+	for _, msg := range msgs(cmd) {
+		if msg, ok := msg.(ScrollMsg); ok {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func TestScrollMsg(t *testing.T) {
+	m := New()
+	m.Write(strings.Repeat("line\n", 10))
+	m.RenderAt(10, 5)
+
+	tests := []struct {
+		name   string
+		scroll func() tea.Cmd
+		want   []ScrollMsg
+	}{
+		{"ScrollTo(2)", func() tea.Cmd { return m.ScrollTo(2) }, []ScrollMsg{{Top: 2}}},
+		{"ScrollTo(2) again", func() tea.Cmd { return m.ScrollTo(2) }, nil},
+		{"ScrollBy(3)", func() tea.Cmd { return m.ScrollBy(3) }, []ScrollMsg{{Top: 5}}},
+		{"j within the viewport", func() tea.Cmd { return press(m, "j") }, nil},
+		{"ctrl+u", func() tea.Cmd { return press(m, "ctrl+u") }, []ScrollMsg{{Top: 4}}},
+		{"wheel up", func() tea.Cmd {
+			return m.handleMouse(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp})
+		}, []ScrollMsg{{Top: 3}}},
+	}
+	for _, tt := range tests {
+		m.RenderAt(10, 5)
+		if got := scrolls(tt.scroll()); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: scrolls = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := scrolls(m.Follow()); len(got) != 1 || !got[0].Tailing {
+		t.Errorf("Follow: scrolls = %v, want one that's tailing", got)
+	}
+}
+
+func TestWrapCache(t *testing.T) {
+	m := WithSoftWrap(New(WithoutStatusbar))
+	m.Write("abcdef\n")
+	assertScreen(t, m, 3, 2, "abc", "def")
+	if got := len(m.wrapCache); got != 1 {
+		t.Errorf("after rendering, wrap cache has %d lines, want 1", got)
+	}
+
+	// the cache doesn't outlive the width or wrap mode it was made for
+	assertScreen(t, m, 4, 2, "abcd", "ef")
+	m.SetWrapMode(true)
+	assertScreen(t, m, 4, 2, "", "abcd")
+
+	// or grow without bound
+	for i := range maxWrapCacheSize + 1 {
+		m.cachedWrap(fmt.Sprint(i), 4)
+	}
+	if got := len(m.wrapCache); got > maxWrapCacheSize {
+		t.Errorf("wrap cache has %d lines, want at most %d", got, maxWrapCacheSize)
+	}
+}
+
+func BenchmarkRenderWrapped(b *testing.B) {
+	m := WithSoftWrap(New())
+	for i := range 200 {
+		m.WriteLine(fmt.Sprintf("%d %s", i, strings.Repeat("a long line that wraps ", 20)))
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.RenderAt(80, 50)
+	}
+}
+
+func TestCarrySGR(t *testing.T) {
+	tests := []struct{ wrapped, want string }{
+		{"plain\nrows", "plain\nrows"},
+		{"ab\x1b[7mcd\nef\x1b[0mgh", "ab\x1b[7mcd\x1b[0m\n\x1b[7mef\x1b[0mgh"},
+		{"\x1b[1m\x1b[31ma\nb\nc\x1b[m", "\x1b[1m\x1b[31ma\x1b[0m\n\x1b[1m\x1b[31mb\x1b[0m\n\x1b[1m\x1b[31mc\x1b[m"},
+		{"\x1b[7ma\x1b[0m\nb", "\x1b[7ma\x1b[0m\nb"},
+	}
+	for _, tt := range tests {
+		if got := carrySGR(tt.wrapped); got != tt.want {
+			t.Errorf("carrySGR(%q) = %q, want %q", tt.wrapped, got, tt.want)
+		}
+	}
+}
+
+func TestWrapStyle(t *testing.T) {
+	m := WithSoftWrap(New(WithoutStatusbar, WithStartAtHead))
+	m.Write("the quick brown fox\nsupercalifragilistic\n")
+
+	assertScreen(t, m, 8, 6, "the quic", "k brown", "fox", "supercal", "ifragili", "stic")
+
+	// words that don't fit on a row by themselves are still broken
+	m.SetWrapStyle(WrapWords)
+	assertScreen(t, m, 8, 6, "the", "quick", "brown", "fox", "supercal", "ifragili")
+}
+
+func TestScrollOff(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 20 {
+		m.WriteLine(fmt.Sprint(i))
+	}
+	m.SetScrollOff(1)
+	m.RenderAt(10, 5)
+
+	// the top edge
+	for range 3 {
+		press(m, "j")
+		m.RenderAt(10, 5)
+	}
+	assertScreen(t, m, 10, 5, "0", "1", "2", "3", "4")
+	press(m, "j")
+	assertScreen(t, m, 10, 5, "1", "2", "3", "4", "5")
+
+	// the bottom edge, where there's no context to keep below the last
+	// line
+	press(m, "2", "0", "G")
+	assertScreen(t, m, 10, 5, "15", "16", "17", "18", "19")
+	for range 3 {
+		press(m, "k")
+		m.RenderAt(10, 5)
+	}
+	assertScreen(t, m, 10, 5, "15", "16", "17", "18", "19")
+	press(m, "k")
+	assertScreen(t, m, 10, 5, "14", "15", "16", "17", "18")
+
+	// more context than fits keeps the current line in the middle
+	m.SetScrollOff(10)
+	press(m, "k")
+	assertScreen(t, m, 10, 5, "12", "13", "14", "15", "16")
+}
+
+func TestWriteBatchMsg(t *testing.T) {
+	m := New()
+	m.Update(WriteBatchMsg{"a\n", "b", "c\nd"})
+	m.Update(WriteMsg("e\n"))
+	if got, want := m.String(), "a\nbc\nde"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// BenchmarkWriteMsg compares writing lines through Update one message at a
+// time with writing them in batches, as main does from stdin.
+func BenchmarkWriteMsg(b *testing.B) {
+	const lines = 1000
+	line := "2024-01-02 15:04:05 INFO request handled in 12ms\n"
+	batch := make(WriteBatchMsg, lines)
+	for i := range batch {
+		batch[i] = line
+	}
+
+	b.Run("single", func(b *testing.B) {
+		m := New()
+		m.SetQuery("ERROR")
+		for i := 0; i < b.N; i++ {
+			for range lines {
+				m.Update(WriteMsg(line))
+			}
+		}
+		b.ReportMetric(float64(b.N*lines)/b.Elapsed().Seconds(), "lines/s")
+	})
+	b.Run("batch", func(b *testing.B) {
+		m := New()
+		m.SetQuery("ERROR")
+		for i := 0; i < b.N; i++ {
+			m.Update(batch)
+		}
+		b.ReportMetric(float64(b.N*lines)/b.Elapsed().Seconds(), "lines/s")
+	})
+}
+
+func TestWriteCRLF(t *testing.T) {
+	m := New()
+	m.Write("a\r\n")
+	m.Write("\r\n")
+	m.Write("b")
+	if got, want := m.lineCount(), 2; got != want {
+		t.Fatalf("lineCount() = %d, want %d", got, want)
+	}
+	if got := []string{m.line(0), m.line(1)}; !slices.Equal(got, []string{"a", ""}) {
+		t.Errorf("lines = %q, want %q", got, []string{"a", ""})
+	}
+	if m.buffer != "b" {
+		t.Errorf("buffer = %q, want %q", m.buffer, "b")
+	}
+
+	// a CRLF split across writes
+	m.Write("c\r")
+	m.Write("\nd\r\n")
+	if got, want := m.String(), "a\n\nbc\nd"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	m := New(WithStartAtHead)
+	for i := range 5000 {
+		m.WriteLine(fmt.Sprintf("line %d", i))
+	}
+
+	m.OpenSearch()
+	stale := m.TypeSearch("12")
+	if !strings.Contains(m.RenderSearchStatus(), "searching…") {
+		t.Errorf("RenderSearchStatus() = %q, want it to say it's searching", m.RenderSearchStatus())
+	}
+
+	// the next keystroke cancels the search for "12"
+	cmd := m.TypeSearch("3")
+	done := stale().(filterDoneMsg)
+	if !errors.Is(done.err, context.Canceled) {
+		t.Fatalf("stale search returned error %v, want it cancelled", done.err)
+	}
+	m.Update(done)
+	if m.queryRe != nil {
+		t.Errorf("stale search applied %v", m.queryRe)
+	}
+
+	m.Update(cmd())
+	if got, want := m.FilteredCount(), 15; got != want {
+		t.Errorf("FilteredCount() = %d, want %d", got, want)
+	}
+	if strings.Contains(m.RenderSearchStatus(), "searching…") {
+		t.Errorf("RenderSearchStatus() = %q, want it done searching", m.RenderSearchStatus())
+	}
+}
+
+func TestSearchTimeout(t *testing.T) {
+	m := New(WithStartAtHead)
+	for i := range 5000 {
+		m.WriteLine(fmt.Sprintf("line %d", i))
+	}
+	m.SetSearchTimeout(time.Nanosecond)
+
+	m.OpenSearch()
+	cmd := m.TypeSearch("1")
+	time.Sleep(time.Millisecond)
+	m.Update(cmd())
+	if !strings.Contains(m.RenderSearchStatus(), "[timed out]") {
+		t.Errorf("RenderSearchStatus() = %q, want it to say the search timed out", m.RenderSearchStatus())
+	}
+	if m.filtering() {
+		t.Error("a search that timed out was applied")
+	}
+}
+
+// TestSearchRace runs searches in the background while lines keep being
+// written and replaced, like typing while tailing a busy log. Run it with
+// -race.
+func TestSearchRace(t *testing.T) {
+	m := New(WithStartAtHead)
+	for i := range 2000 {
+		m.WriteLine(fmt.Sprintf("line %d", i))
+	}
+
+	results := make(chan tea.Msg)
+	var wg sync.WaitGroup
+	m.OpenSearch()
+	// typing, and deleting what was typed
+	for _, query := range []string{"1", "12", "123", "12", "1", "14"} {
+		m.input.SetValue(query)
+		cmd := m.handleBarChange()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- cmd()
+		}()
+
+		for i := range 200 {
+			m.WriteLine(fmt.Sprintf("more %d4", i))
+			m.ReplaceLine(i, fmt.Sprintf("replaced %d", i))
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for msg := range results {
+		m.Update(msg)
+	}
+
+	if got, want := m.Query(), "14"; got != want {
+		t.Fatalf("Query() = %q, want %q", got, want)
+	}
+	got := m.FilteredIndices()
+	m.searchNow()
+	if want := m.FilteredIndices(); !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+}
+
+func TestTailFiltered(t *testing.T) {
+	m := New(WithoutStatusbar)
+	m.SetShowPartialLines(true)
+	m.SetQuery("ERR")
+	for i := range 10 {
+		m.Write(fmt.Sprintf("ERR %d\nok %d\n", i, i))
+	}
+	assertScreen(t, m, 10, 3, "ERR 7", "ERR 8", "ERR 9")
+
+	// lines that don't match don't move the view, even while they're
+	// being written
+	m.Write("ok 10\nok")
+	assertScreen(t, m, 10, 3, "ERR 7", "ERR 8", "ERR 9")
+
+	// the latest match stays in view, even before it's complete
+	m.Write(" 11\nERR")
+	assertScreen(t, m, 10, 3, "ERR 8", "ERR 9", "ERR")
+	m.Write(" 12\n")
+	assertScreen(t, m, 10, 3, "ERR 8", "ERR 9", "ERR 12")
+}
+
+func TestBottomAndFollow(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("1\n2\n3\n4\n")
+
+	// G follows the tail by default
+	press(m, "G")
+	m.Write("5\n")
+	assertScreen(t, m, 10, 3, "3", "4", "5")
+
+	// unless it's set to stay on the bottom
+	m.SetFollowOnBottom(false)
+	press(m, "g", "g")
+	press(m, "G")
+	assertScreen(t, m, 10, 3, "3", "4", "5")
+	m.Write("6\n")
+	assertScreen(t, m, 10, 3, "3", "4", "5")
+
+	// F always follows
+	press(m, "F")
+	assertScreen(t, m, 10, 3, "4", "5", "6")
+	m.Write("7\n")
+	assertScreen(t, m, 10, 3, "5", "6", "7")
+
+	m.ScrollToBottom()
+	m.Write("8\n")
+	assertScreen(t, m, 10, 3, "5", "6", "7")
+	m.Follow()
+	assertScreen(t, m, 10, 3, "6", "7", "8")
+}
+
+func TestDimNonMatches(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("GET /a 200\nGET /b 500\nPOST /c 200\nGET /d 500\n")
+	m.SetSearchScope(SearchScopeViewport)
+	m.SetQuery("500")
+	m.SetDimNonMatches(true)
+
+	// mark dimmed lines, since styles aren't rendered in tests
+	styles := *defaultStyles
+	styles.Dimmed = lipgloss.NewStyle().Transform(func(s string) string { return "~ " + s })
+	got := strings.Split(stripEscapes(m.renderLog(&styles, 20, 4)), "\n")
+	for i, row := range got {
+		got[i] = strings.TrimRight(row, " ")
+	}
+	want := []string{"~ GET /a 200", "GET /b 500", "~ POST /c 200", "GET /d 500"}
+	if !slices.Equal(got, want) {
+		t.Errorf("screen =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFilteredMemory(t *testing.T) {
+	m := New()
+	for i := range 10_000 {
+		m.WriteLine(fmt.Sprintf("line %d", i))
+	}
+	m.SetQuery("line")
+	if got, want := m.FilteredCount(), 10_000; got != want {
+		t.Fatalf("FilteredCount() = %d, want %d", got, want)
+	}
+
+	// searching again reuses the results' memory, rather than allocating
+	// for every match
+	if !raceEnabled {
+		allocs := testing.AllocsPerRun(10, func() { m.setFiltered(m.search()) })
+		if allocs > 10 {
+			t.Errorf("searching again made %v allocations", allocs)
+		}
+	}
+
+	// clearing the filter lets go of the results
+	m.SetQuery("")
+	if m.filtered != nil {
+		t.Errorf("filtered still holds %d lines after clearing the filter", len(m.filtered))
+	}
+}
+
+func BenchmarkFilterToggle(b *testing.B) {
+	m := New()
+	for i := range 100_000 {
+		m.WriteLine(fmt.Sprintf("2024-01-02 15:04:05 INFO request %d handled", i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.SetQuery("INFO")
+		m.SetQuery("")
+	}
+}
+
+func TestOverscroll(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("1\n2\n3\n4\n5\n")
+	m.RenderAt(10, 3)
+
+	// the last line can be scrolled to the top
+	m.ScrollBy(10)
+	assertScreen(t, m, 10, 3, "5", "", "")
+
+	// or only to the bottom
+	m.SetOverscroll(false)
+	m.ScrollTo(0)
+	m.RenderAt(10, 3)
+	m.ScrollBy(10)
+	assertScreen(t, m, 10, 3, "3", "4", "5")
+	m.ScrollTo(4)
+	assertScreen(t, m, 10, 3, "3", "4", "5")
+}
+
+func TestPartialPageAlignment(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("1\n2\n3\n4\n5\n")
+	m.ScrollTo(3)
+	assertScreen(t, m, 10, 4, "4", "5", "", "")
+
+	m.SetPartialPageAlignment(PageAlignBottom)
+	assertScreen(t, m, 10, 4, "", "", "4", "5")
+
+	// a full page isn't affected
+	m.ScrollTo(0)
+	assertScreen(t, m, 10, 4, "1", "2", "3", "4")
+
+	// and while tailing, lines are always at the bottom
+	m.SetPartialPageAlignment(PageAlignTop)
+	m.Follow()
+	assertScreen(t, m, 10, 7, "", "", "1", "2", "3", "4", "5")
+}
+
+func TestWriteLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(m *Model)
+		lines []string
+		buf   string
+	}{
+		{"Write leaves a line without an ending incomplete", func(m *Model) {
+			m.Write("a")
+			m.Write("b\n")
+		}, []string{"ab"}, ""},
+		{"WriteLine completes it", func(m *Model) {
+			m.WriteLine("a")
+			m.WriteLine("b")
+		}, []string{"a", "b"}, ""},
+		{"an incomplete line is completed before", func(m *Model) {
+			m.Write("a")
+			m.WriteLine("b")
+			m.Write("c")
+		}, []string{"a", "b"}, "c"},
+		{"the line ending is optional", func(m *Model) {
+			m.WriteLine("a\n")
+			m.WriteLine("")
+		}, []string{"a", ""}, ""},
+		{"embedded newlines split it", func(m *Model) {
+			m.WriteLine("a\nb")
+		}, []string{"a", "b"}, ""},
+	}
+	for _, tt := range tests {
+		m := New()
+		tt.write(m)
+		var lines []string
+		for i := range m.lineCount() {
+			lines = append(lines, m.line(i))
+		}
+		if !slices.Equal(lines, tt.lines) || m.buffer != tt.buf {
+			t.Errorf("%s: lines, buffer = %q, %q, want %q, %q", tt.name, lines, m.buffer, tt.lines, tt.buf)
+		}
+	}
+}
+
+func TestHeldKey(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 20 {
+		m.WriteLine(fmt.Sprint(i))
+	}
+	m.RenderAt(10, 3)
+
+	// another key lets go of a g, and does what it does as usual
+	press(m, "g", "j", "j", "j")
+	m.RenderAt(10, 3)
+	if m.keys.heldKey != "" {
+		t.Errorf("held key = %q after g j, want none", m.keys.heldKey)
+	}
+	assertScreen(t, m, 10, 3, "1", "2", "3")
+
+	// so a later g doesn't complete a gg
+	press(m, "g")
+	assertScreen(t, m, 10, 3, "1", "2", "3")
+	press(m, "g")
+	assertScreen(t, m, 10, 3, "0", "1", "2")
+
+	// a g times out
+	press(m, "G", "g")
+	m.Update(heldKeyTimeoutMsg{m.keys.heldGen})
+	press(m, "g")
+	if m.keys.heldKey != "g" {
+		t.Errorf("held key = %q, want a new g held after a timeout", m.keys.heldKey)
+	}
+
+	// but not when the timeout is for an earlier g
+	press(m, "j")
+	press(m, "g")
+	m.Update(heldKeyTimeoutMsg{m.keys.heldGen - 1})
+	press(m, "g")
+	assertScreen(t, m, 10, 3, "0", "1", "2")
+}
+
+func TestEmptyPlaceholder(t *testing.T) {
+	m := New(WithoutStatusbar)
+	assertScreen(t, m, 11, 3, "", "  (empty)", "")
+
+	m.Write("a\nb\n")
+	m.SetQuery("zzz")
+	assertScreen(t, m, 21, 3, "", "No matches for /zzz/", "")
+
+	// other filters that leave nothing to show
+	m.SetQuery("")
+	m.SetStreamFilter(StreamStderr)
+	assertScreen(t, m, 12, 1, " No matches")
+
+	m.SetStreamFilter()
+	assertScreen(t, m, 12, 3, "", "a", "b")
+}
+
+func TestWheelScrollLines(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 100 {
+		m.WriteLine(fmt.Sprint(i))
+	}
+	wheel := func(button tea.MouseButton) {
+		m.handleMouse(tea.MouseMsg{Button: button, Action: tea.MouseActionPress})
+		m.RenderAt(10, 2)
+	}
+
+	wheel(tea.MouseButtonWheelDown)
+	assertScreen(t, m, 10, 2, "1", "2")
+
+	m.SetWheelScrollLines(5)
+	wheel(tea.MouseButtonWheelDown)
+	assertScreen(t, m, 10, 2, "6", "7")
+	wheel(tea.MouseButtonWheelUp)
+	assertScreen(t, m, 10, 2, "1", "2")
+
+	// the multiplier is clamped
+	m.SetWheelScrollLines(0)
+	wheel(tea.MouseButtonWheelDown)
+	assertScreen(t, m, 10, 2, "2", "3")
+}
+
+func TestWheelAcceleration(t *testing.T) {
+	w := wheelState{lines: 2, accelerate: true}
+	now := time.Now()
+	var got []int
+	for range 10 {
+		got = append(got, w.distance(now, 1))
+		now = now.Add(wheelAccelWindow / 2)
+	}
+	if want := []int{2, 2, 2, 2, 4, 4, 4, 4, 6, 6}; !slices.Equal(got, want) {
+		t.Errorf("distances = %v, want %v", got, want)
+	}
+
+	// a pause or a change of direction starts over
+	if got := w.distance(now.Add(wheelAccelWindow), 1); got != 2 {
+		t.Errorf("distance after a pause = %d, want 2", got)
+	}
+	w.distance(now, 1)
+	if got := w.distance(now, -1); got != -2 {
+		t.Errorf("distance after changing direction = %d, want -2", got)
+	}
+
+	// it tops out
+	for range 100 {
+		w.distance(now, 1)
+	}
+	if got, want := w.distance(now, 1), 2*maxWheelAccel; got != want {
+		t.Errorf("distance = %d, want at most %d", got, want)
+	}
+}
+
+func TestFilterRule(t *testing.T) {
+	m := New()
+	m.Write("GET /a 200\nGET /health 200\nPOST /b 500\nGET /c 500\n")
+
+	include, exclude := regexp.MustCompile("GET"), regexp.MustCompile("health")
+	tests := []struct {
+		include, exclude *regexp.Regexp
+		want             []int
+	}{
+		{nil, nil, []int{0, 1, 2, 3}},
+		{include, nil, []int{0, 1, 3}},
+		{nil, exclude, []int{0, 2, 3}},
+		{include, exclude, []int{0, 3}},
+	}
+	for _, tt := range tests {
+		m.SetFilterRule(tt.include, tt.exclude)
+		if got := m.FilteredIndices(); !slices.Equal(got, tt.want) {
+			t.Errorf("SetFilterRule(%v, %v): FilteredIndices() = %v, want %v", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+
+	// it composes with the query
+	m.SetQuery("500")
+	if got, want := m.FilteredIndices(), []int{3}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v with a query", got, want)
+	}
+
+	// only include's matches are highlighted, and only without a query
+	if got, want := m.Matches(3), []Match{{7, 10}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(3) = %v, want %v with a query", got, want)
+	}
+	m.SetQuery("")
+	if got, want := m.Matches(3), []Match{{0, 3}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(3) = %v, want %v", got, want)
+	}
+	m.SetFilterRule(nil, exclude)
+	if got := m.Matches(0); got != nil {
+		t.Errorf("Matches(0) = %v, want nil with only exclude", got)
+	}
+}
+
+func TestNoStyleLeak(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.SetWrapMode(true)
+	m.Write("\x1b[31mred and far too long to fit\n\x1b[1;32mgreen\n")
+
+	// no row leaves a style active for the next, or for the statusbar
+	rows := strings.Split(m.Render(defaultStyles, 12, 4), "\n")
+	for i, row := range rows {
+		seqs := sgrRe.FindAllString(row, -1)
+		if last := len(seqs) - 1; last >= 0 && seqs[last] != "\x1b[0m" && seqs[last] != "\x1b[m" {
+			t.Errorf("row %d = %q leaves %q active", i, row, seqs[last])
+		}
+	}
+	if got, want := stripEscapes(rows[0]), "red and far "; got != want {
+		t.Errorf("row 0 = %q, want %q", got, want)
+	}
+}
+
+func TestCountPartialLine(t *testing.T) {
+	m := New(WithStartAtHead)
+	m.SetShowPartialLines(true)
+	m.Write("a\nb\n")
+
+	// counted by default
+	m.Write("c")
+	if got, want := m.RenderLineStatus(), "1 of 3"; got != want {
+		t.Errorf("RenderLineStatus() = %q, want %q", got, want)
+	}
+
+	// or not, so the count stays steady as the line grows
+	m.SetCountPartialLine(false)
+	for _, c := range "ontinued" {
+		m.Write(string(c))
+		if got, want := m.RenderLineStatus(), "1 of 2"; got != want {
+			t.Fatalf("RenderLineStatus() = %q after writing %q, want %q", got, m.buffer, want)
+		}
+	}
+	m.Write("\n")
+	if got, want := m.RenderLineStatus(), "1 of 3"; got != want {
+		t.Errorf("RenderLineStatus() = %q once the line is complete, want %q", got, want)
+	}
+}
+
+func TestWriteMsg(t *testing.T) {
+	// an embedding program forwards messages without handling them itself
+	var model tea.Model = New(WithoutStatusbar)
+	for _, msg := range []tea.Msg{WriteMsg("a\nb"), WriteMsg("c\n"), WriteBatchMsg{"d\n", "e\n"}} {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		if cmd != nil {
+			t.Errorf("Update(%#v) returned a command", msg)
+		}
+	}
+	assertScreen(t, model.(*Model), 10, 4, "a", "bc", "d", "e")
+}
+
+func TestTailPartialLine(t *testing.T) {
+	m := New(WithoutStatusbar)
+	m.SetShowPartialLines(true)
+	m.Write("1\n2\n3\n")
+
+	// a partial line that wraps fills the bottom rows, without a blank row
+	// before it
+	m.Write("abcdefghij")
+	assertScreen(t, m, 4, 4, "3", "abcd", "efgh", "ij")
+
+	// even if it's taller than the viewport
+	m.Write("klmnopqrstuv")
+	assertScreen(t, m, 4, 3, "mnop", "qrst", "uv")
+
+	// half a CRLF isn't a row of its own
+	m = New(WithoutStatusbar)
+	m.SetShowPartialLines(true)
+	m.Write("1\n2\n\r")
+	assertScreen(t, m, 4, 2, "1", "2")
+}
+
+func TestRuler(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.SetRuler(true)
+	m.Write("0123456789abcdefghij0123\n")
+	assertScreen(t, m, 25, 2,
+		"0·········10········20···",
+		"0123456789abcdefghij0123",
+	)
+
+	// ticks count columns of the log, past any gutter
+	m.SetRelativeLineNumbers(true)
+	assertScreen(t, m, 25, 2,
+		"  0·········10········20·",
+		"1 0123456789abcdefghij012",
+	)
+	m.SetRelativeLineNumbers(false)
+
+	// and of the lines as written, when they're dedented
+	m = New(WithoutStatusbar, WithStartAtHead)
+	m.SetRuler(true)
+	m.SetDedent(true)
+	m.Write("        indented\n          more\n")
+	assertScreen(t, m, 14, 3, "··10········20", "indented", "  more")
+
+	// a viewport only tall enough for a line leaves the ruler out
+	assertScreen(t, m, 14, 1, "indented")
+}
@@ -0,0 +1,120 @@
+// Package logviewtest drives a [logview.Model] through a sequence of
+// events and captures what it renders, for comparing against golden frames
+// in tests.
+package logviewtest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cgsdev0/logfilter/logview"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCmds bounds how many commands Run follows after each event, in case
+// they keep producing more.
+const maxCmds = 1000
+
+// cmdTimeout is how long Run waits for a command's message. Commands that
+// take longer, like the search bar's cursor blinking, are abandoned.
+const cmdTimeout = 100 * time.Millisecond
+
+// Run applies events to m, in order, and returns what it renders at the
+// end. Events are usually made with [Write], [Key], [Type], and [Resize],
+// but any other message is passed to m.Update.
+//
+// The commands returned by m.Update are run to completion, and their
+// messages passed back to m.Update, so that asynchronous work like
+// searching has finished before the next event. Commands that take longer
+// than a moment, like timers, are abandoned.
+func Run(m *logview.Model, events ...tea.Msg) string {
+	for _, event := range events {
+		switch event := event.(type) {
+		case writeEvent:
+			m.Write(string(event))
+		case typeEvent:
+			for _, r := range event {
+				_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+				runCmds(m, cmd)
+			}
+		case tea.WindowSizeMsg:
+			m.SetDimensions(event.Width, event.Height)
+		default:
+			_, cmd := m.Update(event)
+			runCmds(m, cmd)
+		}
+	}
+	return m.View()
+}
+
+// runCmds runs cmd, and any commands that result from handling its
+// messages, until there are none left.
+func runCmds(m *logview.Model, cmd tea.Cmd) {
+	queue := []tea.Cmd{cmd}
+	for n := 0; len(queue) > 0 && n < maxCmds; n++ {
+		cmd, queue = queue[0], queue[1:]
+		if cmd == nil {
+			continue
+		}
+		switch msg := runCmd(cmd).(type) {
+		case nil, tea.QuitMsg:
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		default:
+			_, cmd := m.Update(msg)
+			queue = append(queue, cmd)
+		}
+	}
+}
+
+// runCmd returns cmd's message, or nil if it takes too long.
+func runCmd(cmd tea.Cmd) tea.Msg {
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(cmdTimeout):
+		return nil
+	}
+}
+
+type writeEvent string
+
+// Write returns an event that writes content to the model.
+func Write(content string) tea.Msg { return writeEvent(content) }
+
+// Resize returns an event that sets the model's dimensions.
+func Resize(width, height int) tea.Msg {
+	return tea.WindowSizeMsg{Width: width, Height: height}
+}
+
+// keyTypes maps key names, like "enter" and "ctrl+d", to their types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for k := tea.KeyType(-256); k <= 256; k++ {
+		if name := k.String(); name != "" {
+			types[name] = k
+		}
+	}
+	return types
+}()
+
+// Key returns a key press event for name, spelled like [tea.Key.String]:
+// "j", "G", "enter", "ctrl+d", "alt+f", and so on.
+func Key(name string) tea.Msg {
+	alt := strings.HasPrefix(name, "alt+") && name != "alt+"
+	if alt {
+		name = strings.TrimPrefix(name, "alt+")
+	}
+	if k, ok := keyTypes[name]; ok && len([]rune(name)) > 1 {
+		return tea.KeyMsg{Type: k, Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name), Alt: alt}
+}
+
+type typeEvent string
+
+// Type returns an event that presses a key for each character of text, as
+// if it were typed, like into the search bar.
+func Type(text string) tea.Msg { return typeEvent(text) }
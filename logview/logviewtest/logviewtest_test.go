@@ -0,0 +1,70 @@
+package logviewtest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cgsdev0/logfilter/logview"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rows splits a rendered frame into rows, with trailing spaces trimmed.
+func rows(frame string) []string {
+	rows := strings.Split(frame, "\n")
+	for i, row := range rows {
+		rows[i] = strings.TrimRight(row, " ")
+	}
+	return rows
+}
+
+func TestRun(t *testing.T) {
+	m := logview.New()
+	tests := []struct {
+		name   string
+		events []tea.Msg
+		want   []string
+	}{
+		{"tailing", []tea.Msg{Resize(20, 4), Write("a\nb\nc\nd\ne\n")}, []string{"c", "d", "e", ""}},
+		{"gg", []tea.Msg{Key("g"), Key("g")}, []string{"a", "b", "c", "1 of 5"}},
+		{"ctrl+d", []tea.Msg{Key("ctrl+d")}, []string{"b", "c", "d", "2 of 5"}},
+		{"gg", []tea.Msg{Key("g"), Key("g")}, []string{"a", "b", "c", "1 of 5"}},
+		{"search", []tea.Msg{Key("/"), Type("[ae]"), Key("enter")}, []string{"a", "e", "", "1 of 2  /[ae] (2)"}},
+	}
+	// the events add up, so each frame follows on from the last
+	for _, tt := range tests {
+		got := rows(Run(m, tt.events...))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: frame = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want tea.KeyMsg
+	}{
+		{"j", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}},
+		{"G", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}},
+		{"é", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("é")}},
+		{"enter", tea.KeyMsg{Type: tea.KeyEnter}},
+		{"esc", tea.KeyMsg{Type: tea.KeyEscape}},
+		{"ctrl+d", tea.KeyMsg{Type: tea.KeyCtrlD}},
+		{"pgdown", tea.KeyMsg{Type: tea.KeyPgDown}},
+		{"f1", tea.KeyMsg{Type: tea.KeyF1}},
+		{"alt+f", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f"), Alt: true}},
+		{"alt+enter", tea.KeyMsg{Type: tea.KeyEnter, Alt: true}},
+		{"alt++", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+"), Alt: true}},
+		{"+", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")}},
+	}
+	for _, tt := range tests {
+		got := Key(tt.name)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Key(%q) = %#v, want %#v", tt.name, got, tt.want)
+		}
+		if s := got.(tea.KeyMsg).String(); s != tt.name {
+			t.Errorf("Key(%q).String() = %q", tt.name, s)
+		}
+	}
+}
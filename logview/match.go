@@ -0,0 +1,381 @@
+package logview
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NextMatch moves the current line down to the next line in the view
+// matching the query, if there is one. The returned command emits a
+// [ScrollMsg] if the viewport moved.
+func (m *Model) NextMatch() tea.Cmd {
+	return m.jumpToMatch(m.findMatch(m.cursorPosition()+1, 1))
+}
+
+// PrevMatch is like NextMatch, but moves up.
+func (m *Model) PrevMatch() tea.Cmd {
+	return m.jumpToMatch(m.findMatch(m.cursorPosition()-1, -1))
+}
+
+// FirstMatch returns the index of the earliest line in the view matching
+// the query, or -1 if there isn't one.
+func (m *Model) FirstMatch() int {
+	if pos := m.findMatch(m.logOrder(0), m.logStep()); pos >= 0 {
+		return m.viewIndex(pos)
+	}
+	return -1
+}
+
+// LastMatch returns the index of the latest line in the view matching the
+// query, or -1 if there isn't one.
+func (m *Model) LastMatch() int {
+	if pos := m.findMatch(m.logOrder(m.viewLen()-1), -m.logStep()); pos >= 0 {
+		return m.viewIndex(pos)
+	}
+	return -1
+}
+
+// logOrder maps the ith position in log order to a position in the view,
+// which is the other way around in reverse.
+func (m *Model) logOrder(i int) int {
+	if m.reverse {
+		return m.viewLen() - 1 - i
+	}
+	return i
+}
+
+// logStep is the direction of increasing line indices in the view.
+func (m *Model) logStep() int {
+	if m.reverse {
+		return -1
+	}
+	return 1
+}
+
+// findMatch returns the first position in the view, starting at from and
+// moving by step, whose line matches the query, or -1 if there isn't one.
+func (m *Model) findMatch(from, step int) int {
+	if m.highlightRe() == nil {
+		return -1
+	}
+	for pos := from; pos >= 0 && pos < m.viewLen(); pos += step {
+		if m.isMatch(m.viewIndex(pos)) {
+			return pos
+		}
+	}
+	return -1
+}
+
+// isMatch reports whether the line at lineno matches the query, or the
+// find if there is one.
+func (m *Model) isMatch(lineno int) bool {
+	if m.findRe != nil {
+		return m.findRe.MatchString(m.matchText(m.line(lineno)))
+	}
+	// when filtering on the query, every line in the view matches, other
+	// than context
+	if m.filtering() && m.searchScope == SearchScopeAll {
+		return !m.isContext(lineno)
+	}
+	return m.highlightRe().MatchString(m.matchText(m.line(lineno)))
+}
+
+// jumpToMatch moves the current line to the match at position pos in the
+// view, and records its ordinal for the statusbar.
+func (m *Model) jumpToMatch(pos int) tea.Cmd {
+	if pos < 0 {
+		return nil
+	}
+	// only where the viewport ends up is reported
+	before, edge := m.scrollPosition, m.edge
+	m.moveCursorTo(pos)
+	m.edge = edge
+	m.revealMatch(pos)
+
+	m.jumpedMatch = m.viewIndex(pos)
+	matches := m.matchingLines()
+	i, found := slices.BinarySearch(matches, m.jumpedMatch)
+	if found {
+		i++
+	}
+	m.matchOrdinal, m.matchTotal = i, len(matches)
+	return m.scrollCmd(before)
+}
+
+// matchCache caches the indices of the lines in the view matching the
+// query, in log order, so they only need to be searched for once rather
+// than on every jump between matches.
+type matchCache struct {
+	key matchKey
+
+	// searched is how many positions in the view, in log order, have been
+	// searched, and last is the index of the last line searched.
+	searched int
+	last     int
+	matches  []int
+}
+
+// matchKey is what the matches in the view depend on, other than lines
+// being written.
+type matchKey struct {
+	re          *regexp.Regexp
+	searchGen   uint64
+	header      int
+	folds       int
+	foldedLines int
+}
+
+// matchingLines returns the indices of the lines in the view matching the
+// query, in log order, searching any written since it was last called.
+func (m *Model) matchingLines() []int {
+	c := &m.matchCache
+	key := matchKey{m.highlightRe(), m.searchGen, m.headerLen(), len(m.folds), m.foldedLines()}
+	n := m.viewLen()
+	if c.key != key || c.searched > n || (c.searched > 0 && m.viewIndex(m.logOrder(c.searched-1)) != c.last) {
+		*c = matchCache{key: key}
+	}
+	for ; c.searched < n; c.searched++ {
+		c.last = m.viewIndex(m.logOrder(c.searched))
+		if m.isMatch(c.last) {
+			c.matches = append(c.matches, c.last)
+		}
+	}
+	return c.matches
+}
+
+// renderMatchStatus shows which match the current line is, if it was
+// reached by jumping between matches.
+func (m *Model) renderMatchStatus() string {
+	if m.matchTotal == 0 || m.scrollPosition < 0 || m.CurrentLine() != m.jumpedMatch {
+		return ""
+	}
+	return fmt.Sprintf(" [%d/%d]", m.matchOrdinal, m.matchTotal)
+}
+
+// wordRe matches the words that CurrentWord picks from: runs of letters,
+// digits, and underscores, joined by dots or dashes like "req-1234" or
+// "foo.bar".
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}_]+(?:[.-][\p{L}\p{N}_]+)*`)
+
+// CurrentWord returns the word on the current line to search for: the one
+// at the first match of the query, if the line matches, or else the first
+// one. If the line has no words, the whole line is returned.
+func (m *Model) CurrentWord() string {
+	lineno := m.CurrentLine()
+	if lineno < 0 {
+		return ""
+	}
+	line := escapeRe.ReplaceAllString(m.line(lineno), "")
+	words := wordRe.FindAllStringIndex(line, -1)
+	if len(words) == 0 {
+		return line
+	}
+
+	word := words[0]
+	if re := m.highlightRe(); re != nil {
+		if loc := re.FindStringIndex(line); loc != nil {
+			for _, w := range words {
+				if w[1] > loc[0] {
+					word = w
+					break
+				}
+			}
+		}
+	}
+	return line[word[0]:word[1]]
+}
+
+// SearchCurrentWord sets the query to [Model.CurrentWord], taken literally,
+// and moves to its next occurrence, like `*` in vim. If wholeWord is set,
+// it only matches where it isn't part of a longer word.
+func (m *Model) SearchCurrentWord(wholeWord bool) tea.Cmd {
+	word := m.CurrentWord()
+	if word == "" {
+		return nil
+	}
+	pattern := regexp.QuoteMeta(word)
+	if wholeWord {
+		// \b only knows about ASCII word characters
+		if isASCIIWord(word[0]) {
+			pattern = `\b` + pattern
+		}
+		if isASCIIWord(word[len(word)-1]) {
+			pattern += `\b`
+		}
+	}
+
+	// stay where we were, then move on to the next match after the
+	// current line
+	lineno := m.CurrentLine()
+	restore := m.keepViewport()
+	m.SetQuery(pattern)
+	restore()
+	from := m.cursorPosition()
+	if m.CurrentLine() == lineno {
+		from++
+	}
+	return m.jumpToMatch(m.findMatch(from, 1))
+}
+
+// isASCIIWord reports whether c is an ASCII word character, as matched by
+// \w.
+func isASCIIWord(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// SetCenterOnMatch sets whether jumping to a match scrolls it to the middle
+// of the viewport, rather than just into view.
+func (m *Model) SetCenterOnMatch(center bool) { m.centerOnMatch = center }
+
+// revealMatch scrolls the viewport so the match on the line at position pos
+// is visible, even if the line is taller than the viewport.
+func (m *Model) revealMatch(pos int) {
+	m.rowOffset = 0
+	before := m.scrollPosition
+	dedent := m.visibleIndent(m.bodyHeight)
+	if rows := m.rowsAt(pos, dedent); rows > m.bodyHeight && m.bodyHeight > 0 {
+		// show the match's row, with context above it like at the top
+		// of the viewport
+		context := m.scrollOff
+		if m.centerOnMatch {
+			context = m.bodyHeight / 2
+		}
+		m.scrollPosition = pos
+		m.rowOffset = clamp(0, rows-m.bodyHeight, m.matchRow(pos, dedent)-context)
+		m.rowOffsetLine = m.viewIndex(pos)
+	} else if m.centerOnMatch {
+		m.centerScroll(pos)
+	}
+
+	// until the next render, assume the viewport still fits as many lines
+	shift := m.scrollPosition - before
+	m.firstDisplayedLine += shift
+	m.lastDisplayedLine += shift
+}
+
+// CenterOn scrolls the viewport so the line at index line is in the middle
+// of it, taking into account how many rows the lines above it wrap to, and
+// makes it the current line. Near the start or end of the view, it's as
+// close to the middle as scrolling allows. If the line isn't in the view,
+// the next one that is is centered instead. The returned command emits a
+// [ScrollMsg] if the viewport moved.
+func (m *Model) CenterOn(line int) tea.Cmd {
+	if m.viewLen() == 0 {
+		return nil
+	}
+	before := m.scrollPosition
+	pos := m.viewPosition(line)
+	m.rowOffset = 0
+	if m.bodyHeight == 0 {
+		// until the first render, there's no telling where the middle is
+		m.scrollPosition = pos
+	} else {
+		m.centerScroll(pos)
+	}
+	m.scrollPosition = clamp(0, max(0, m.maxScroll()), m.scrollPosition)
+	m.cursor = pos
+	return m.scrollCmd(before)
+}
+
+// centerScroll scrolls the viewport so the line at position pos is in the
+// middle of it, or as close as the start of the log allows.
+func (m *Model) centerScroll(pos int) {
+	dedent := m.visibleIndent(m.bodyHeight)
+	above := (m.bodyHeight - m.rowsAt(pos, dedent)) / 2
+	top := pos
+	for ; top > 0; top-- {
+		rows := m.rowsAt(top-1, dedent)
+		if rows > above {
+			break
+		}
+		above -= rows
+	}
+	m.scrollPosition = top
+}
+
+// rowsAt returns how many rows the line at position pos wraps to, with the
+// leading whitespace dedent trimmed.
+func (m *Model) rowsAt(pos int, dedent string) int {
+	line := m.displayLine(defaultStyles, m.viewIndex(pos), dedent)
+	_, rows := m.cachedWrap(line, m.contentWidth(m.bodyWidth))
+	return rows
+}
+
+// matchRow returns which wrapped row of the line at position pos, with the
+// leading whitespace dedent trimmed, the first match is on.
+func (m *Model) matchRow(pos int, dedent string) int {
+	line := m.transformLine(defaultStyles, strings.TrimPrefix(m.line(m.viewIndex(pos)), dedent))
+	matches := m.findMatches(line)
+	if matches == nil {
+		return 0
+	}
+	// wrap everything up to and including the first character of the
+	// match; it ends on the match's row
+	start := matches[0].Start
+	_, first := utf8.DecodeRuneInString(line[start:])
+	_, rows := m.cachedWrap(line[:start+first], m.contentWidth(m.bodyWidth))
+	return rows - 1
+}
+
+// topRowOffset returns how many rows of the line at the top of the
+// viewport are scrolled out of view.
+func (m *Model) topRowOffset() int {
+	pos := m.scrollPosition
+	if m.rowOffset == 0 || pos < 0 || pos >= m.viewLen() || m.viewIndex(pos) != m.rowOffsetLine {
+		return 0
+	}
+	return m.rowOffset
+}
+
+// occurrenceCounts caches the number of matches of queryRe in the first
+// searched lines of the log, so they only need to be counted once.
+type occurrenceCounts struct {
+	queryRe     *regexp.Regexp
+	searched    int
+	lines       int
+	occurrences int
+}
+
+// OccurrenceCount returns the total number of matches of the query across
+// every line, counting each match within a line separately.
+func (m *Model) OccurrenceCount() int {
+	_, occurrences := m.countOccurrences()
+	return occurrences
+}
+
+// SetShowOccurrences sets whether the statusbar shows how many lines match
+// the query, and how many matches there are in total.
+func (m *Model) SetShowOccurrences(show bool) { m.shouldShowOccurrences = show }
+
+// countOccurrences returns the number of lines matching the query, and the
+// total number of matches within them.
+func (m *Model) countOccurrences() (lines, occurrences int) {
+	c := &m.occurrences
+	if c.queryRe != m.highlightRe() {
+		*c = occurrenceCounts{queryRe: m.highlightRe()}
+	}
+	if c.queryRe == nil {
+		return 0, 0
+	}
+	for ; c.searched < m.lineCount(); c.searched++ {
+		if n := m.countMatches(c.queryRe, m.line(c.searched)); n > 0 {
+			c.lines++
+			c.occurrences += n
+		}
+	}
+	return c.lines, c.occurrences
+}
+
+// renderOccurrences shows how many lines and matches there are, if enabled.
+func (m *Model) renderOccurrences() string {
+	if !m.shouldShowOccurrences || m.highlightRe() == nil {
+		return ""
+	}
+	lines, occurrences := m.countOccurrences()
+	return fmt.Sprintf(" %d lines / %d matches", lines, occurrences)
+}
@@ -0,0 +1,253 @@
+package logview
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchStatus(t *testing.T) {
+	m := New()
+	m.Write("a x\nb\nc x\nd x\n")
+	m.SetFind("x")
+	m.RenderAt(20, 3)
+
+	m.ScrollTo(0)
+	m.NextMatch()
+	if got := m.renderMatchStatus(); got != " [2/3]" {
+		t.Errorf("after n, match status = %q, want %q", got, " [2/3]")
+	}
+
+	// lines written since are counted too
+	m.Write("e x\n")
+	m.NextMatch()
+	if got := m.renderMatchStatus(); got != " [3/4]" {
+		t.Errorf("after writing, match status = %q, want %q", got, " [3/4]")
+	}
+
+	// and so are replaced lines
+	m.ReplaceLine(0, "a")
+	m.PrevMatch()
+	if got := m.renderMatchStatus(); got != " [1/3]" {
+		t.Errorf("after replacing, match status = %q, want %q", got, " [1/3]")
+	}
+}
+
+func BenchmarkNextMatch(b *testing.B) {
+	m := New()
+	m.Write(strings.Repeat("GET /index 200\nGET /login 500\n", 50000))
+	m.SetFind("500")
+	m.RenderAt(80, 24)
+	m.ScrollTo(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if m.NextMatch() == nil {
+			m.ScrollTo(0)
+		}
+	}
+}
+
+func TestCenterOn(t *testing.T) {
+	m := New()
+	for i := range 20 {
+		m.WriteLine(fmt.Sprint(i))
+	}
+	m.RenderAt(10, 6) // 5 rows of lines
+
+	for _, tt := range []struct{ line, top int }{{10, 8}, {0, 0}, {1, 0}, {19, 17}} {
+		m.CenterOn(tt.line)
+		if top, tailing := m.ScrollPosition(); top != tt.top || tailing {
+			t.Errorf("CenterOn(%d): scroll position = %d, %v, want %d", tt.line, top, tailing, tt.top)
+		}
+		if got := m.CurrentLine(); got != tt.line {
+			t.Errorf("CenterOn(%d): current line = %d", tt.line, got)
+		}
+	}
+}
+
+func TestCenterOnWrapped(t *testing.T) {
+	tests := []struct {
+		hardwrap bool
+		line     int
+		top      int
+	}{
+		// lines 3, 8, and 17 take up 3 rows, so fewer lines fit above
+		{false, 10, 9},
+		{false, 9, 9},
+		{false, 8, 7},
+		{false, 3, 2},
+		{false, 4, 4},
+		{false, 2, 0},
+		{false, 16, 14},
+		{false, 19, 17},
+		// truncated, they take up 1
+		{true, 10, 8},
+		{true, 8, 6},
+		{true, 3, 1},
+		{true, 1, 0},
+		{true, 17, 15},
+		{true, 19, 15},
+	}
+	for _, tt := range tests {
+		m := New()
+		m.SetWrapMode(tt.hardwrap)
+		// so that scrolling stops short of centering the last lines
+		m.SetOverscroll(false)
+		for i := range 20 {
+			if i == 3 || i == 8 || i == 17 {
+				m.WriteLine(fmt.Sprintf("%d %s", i, strings.Repeat("x", 22)))
+			} else {
+				m.WriteLine(fmt.Sprint(i))
+			}
+		}
+		m.RenderAt(10, 6) // 5 rows of lines
+
+		m.CenterOn(tt.line)
+		if top, tailing := m.ScrollPosition(); top != tt.top || tailing {
+			t.Errorf("hard wrap %v: CenterOn(%d): scroll position = %d, %v, want %d", tt.hardwrap, tt.line, top, tailing, tt.top)
+		}
+		if got := m.CurrentLine(); got != tt.line {
+			t.Errorf("hard wrap %v: CenterOn(%d): current line = %d", tt.hardwrap, tt.line, got)
+		}
+	}
+}
+
+func TestHighlightCache(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 1000 {
+		m.WriteLine(fmt.Sprintf("line %d", i))
+	}
+	m.SetSearchScope(SearchScopeViewport)
+	m.SetQuery("line")
+
+	// only the displayed lines are highlighted
+	m.RenderLog(20, 10)
+	if got, want := len(m.highlightCache), 10; got != want {
+		t.Errorf("highlighted %d lines, want %d", got, want)
+	}
+	m.ScrollTo(5)
+	m.RenderLog(20, 10)
+	if got, want := len(m.highlightCache), 15; got != want {
+		t.Errorf("highlighted %d lines, want %d", got, want)
+	}
+
+	// changing the query evicts them
+	m.SetQuery("1")
+	m.RenderLog(20, 10)
+	if got, want := len(m.highlightCache), 10; got != want {
+		t.Errorf("highlighted %d lines after changing the query, want %d", got, want)
+	}
+	if m.highlightCache["line 5"] != "line 5" {
+		t.Errorf("highlighted %q for the old query", m.highlightCache["line 5"])
+	}
+
+	// and the cache is bounded
+	for pos := 0; pos < 1000; pos += 10 {
+		m.ScrollTo(pos)
+		m.RenderLog(20, 10)
+		if got := len(m.highlightCache); got > maxHighlightCacheSize {
+			t.Fatalf("highlight cache has %d lines, want at most %d", got, maxHighlightCacheSize)
+		}
+	}
+}
+
+func BenchmarkScrollHighlighted(b *testing.B) {
+	m := New(WithStartAtHead)
+	for i := range 100_000 {
+		m.WriteLine(fmt.Sprintf("2024-01-02 15:04:05 INFO request %d handled in %dms", i, i%100))
+	}
+	m.SetSearchScope(SearchScopeViewport)
+	m.SetQuery(`\d+ms`)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// scroll down a line at a time
+		m.ScrollTo(i % 50_000)
+		m.RenderLog(120, 50)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	m := New()
+	m.Write("abcabc\naaa\n\x1b[31mred 31\x1b[0m\nnone\n")
+	if got := m.Matches(0); got != nil {
+		t.Errorf("Matches(0) = %v without a query, want nil", got)
+	}
+
+	tests := []struct {
+		query string
+		line  int
+		want  []Match
+	}{
+		{"bc", 0, []Match{{1, 3}, {4, 6}}},
+		// adjacent matches are kept apart
+		{"a", 1, []Match{{0, 1}, {1, 2}, {2, 3}}},
+		// and overlapping ones are found left to right, like regexp
+		{"aa", 1, []Match{{0, 2}}},
+		// matches within escape sequences are left out
+		{"31", 2, []Match{{9, 11}}},
+		{"x", 3, nil},
+		{"x", 10, nil},
+	}
+	m.SetSearchScope(SearchScopeViewport)
+	for _, tt := range tests {
+		m.SetQuery(tt.query)
+		if got := m.Matches(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("query %q: Matches(%d) = %v, want %v", tt.query, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestMatchStripANSI(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("\x1b[1mERROR\x1b[0m: \x1b[31mdisk\x1b[0m full\nERROR: disk ok\nplain\n")
+
+	m.SetQuery("ERROR: disk")
+	if got, want := m.FilteredIndices(), []int{1}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v matching raw text", got, want)
+	}
+
+	m.SetMatchStripANSI(true)
+	if got, want := m.FilteredIndices(), []int{0, 1}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v matching visible text", got, want)
+	}
+	assertScreen(t, m, 20, 2, "ERROR: disk full", "ERROR: disk ok")
+
+	// matches refer to the line as written, spanning the escape sequences
+	// within them
+	if got, want := m.Matches(0), []Match{{4, 24}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(0) = %v, want %v", got, want)
+	}
+	if got, want := m.Matches(1), []Match{{0, 11}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(1) = %v, want %v", got, want)
+	}
+}
+
+func TestRegexpSyntax(t *testing.T) {
+	m := New()
+	m.Write("abcd\n")
+	m.SetSearchScope(SearchScopeViewport)
+	m.SetQuery("a|ab|abc")
+
+	// leftmost-first takes the first alternative that matches
+	if got, want := m.Matches(0), []Match{{0, 1}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(0) = %v, want %v", got, want)
+	}
+
+	// leftmost-longest takes the longest
+	m.SetRegexpSyntax(true)
+	if got, want := m.Matches(0), []Match{{0, 3}}; !slices.Equal(got, want) {
+		t.Errorf("POSIX Matches(0) = %v, want %v", got, want)
+	}
+
+	// and so does the find
+	m.SetFind("b|bcd")
+	if got, want := m.Matches(0), []Match{{1, 4}}; !slices.Equal(got, want) {
+		t.Errorf("POSIX Matches(0) = %v, want %v for the find", got, want)
+	}
+	m.SetRegexpSyntax(false)
+	if got, want := m.Matches(0), []Match{{1, 2}}; !slices.Equal(got, want) {
+		t.Errorf("Matches(0) = %v, want %v for the find", got, want)
+	}
+}
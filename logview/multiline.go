@@ -0,0 +1,134 @@
+package logview
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// multilineWindow is how many lines are joined together and matched at
+// once in multiline mode, bounding the memory used on huge logs.
+const multilineWindow = 4096
+
+// multilineSpan is the most lines a multiline match can span. Windows
+// overlap by this much, so that matches across their boundaries are found.
+const multilineSpan = 64
+
+// SetMultilineMatch sets whether, when filtering, the query is matched
+// against the log as one string, with "\n" between lines, rather than
+// against each line separately. Every line that's part of a match passes
+// the filter, so a pattern like `BEGIN\n.*\nEND` selects three-line
+// blocks. "^" and "$" match at the start and end of each line, and a match
+// can span up to 64 lines.
+//
+// Highlighting and jumping between matches still work line by line.
+func (m *Model) SetMultilineMatch(multiline bool) {
+	m.multilineMatch = multiline
+	m.searchNow()
+}
+
+// multilining reports whether the filter is matching across lines.
+func (m *Model) multilining() bool {
+	return m.multilineMatch && m.queryRe != nil && m.searchScope == SearchScopeAll
+}
+
+// searchFunc returns a function that searches a snapshot of the log for
+// the lines passing the filter, with queryRe as the query, appending their
+// indices to results. Like lineMatcher, it doesn't refer back to the model.
+func (m *Model) searchFunc(queryRe *regexp.Regexp) func(ctx context.Context, lines lineSnapshot, results []int) ([]int, error) {
+	if !m.multilineMatch || queryRe == nil || m.searchScope != SearchScopeAll {
+		match := m.lineMatcher(queryRe)
+		return func(ctx context.Context, lines lineSnapshot, results []int) ([]int, error) {
+			return searchSnapshot(ctx, match, lines, results)
+		}
+	}
+
+	match, strip := m.lineMatcher(nil), m.matchStripANSI
+	return func(ctx context.Context, lines lineSnapshot, results []int) ([]int, error) {
+		matched, err := multilineSearch(ctx, queryRe, lines, 0, lines.len(), strip, results)
+		if err != nil {
+			return nil, err
+		}
+		return slices.DeleteFunc(matched, func(i int) bool {
+			return !match(lines.line(i), lines.stream(i))
+		}), nil
+	}
+}
+
+// multilineSearch appends the indices of the lines from from to to that
+// are part of a match of queryRe against the lines joined by "\n" to
+// results, stripping their escape sequences first if strip is set. The
+// lines are joined a window at a time.
+func multilineSearch(ctx context.Context, queryRe *regexp.Regexp, lines lineSnapshot, from, to int, strip bool, results []int) ([]int, error) {
+	var (
+		next    = from
+		joined  strings.Builder
+		offsets []int
+	)
+	for start := from; start < to; start += multilineWindow - multilineSpan {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := min(to, start+multilineWindow)
+		joined.Reset()
+		offsets = offsets[:0]
+		for i := start; i < end; i++ {
+			if i > start {
+				joined.WriteByte('\n')
+			}
+			offsets = append(offsets, joined.Len())
+			line := lines.line(i)
+			if strip {
+				line = stripEscapes(line)
+			}
+			joined.WriteString(line)
+		}
+
+		for _, loc := range queryRe.FindAllStringIndex(joined.String(), -1) {
+			first := start + lineAt(offsets, loc[0])
+			last := start + lineAt(offsets, max(loc[0], loc[1]-1))
+			// the windows overlap, so some lines were already found
+			for i := max(first, next); i <= last; i++ {
+				results = append(results, i)
+			}
+			next = max(next, last+1)
+		}
+		if end == to {
+			break
+		}
+	}
+	return results, nil
+}
+
+// lineAt returns which of the joined lines starting at offsets contains
+// the byte at pos.
+func lineAt(offsets []int, pos int) int {
+	return sort.Search(len(offsets), func(i int) bool { return offsets[i] > pos }) - 1
+}
+
+// filterFrom adds the lines from index from onwards that pass the filter,
+// once they've been written. In multiline mode, they can complete matches
+// starting on earlier lines, which are added too.
+func (m *Model) filterFrom(from int) {
+	n := m.lineCount()
+	var found []int
+	if !m.multilining() {
+		for i := from; i < n; i++ {
+			if m.matchLine(i) {
+				found = append(found, i)
+			}
+		}
+	} else {
+		lines, match := m.snapshot(), m.lineMatcher(nil)
+		matched, _ := multilineSearch(context.Background(), m.queryRe, lines, max(0, from-multilineSpan+1), n, m.matchStripANSI, nil)
+		for _, i := range matched {
+			if match(lines.line(i), lines.stream(i)) {
+				found = append(found, i)
+			}
+		}
+	}
+	m.addMatches(found, from)
+}
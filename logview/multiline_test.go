@@ -0,0 +1,54 @@
+package logview
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMultilineMatch(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("noise\nBEGIN\nbody\nEND\nBEGIN\nEND\nnoise\nBEGIN\nmore\nEND\n")
+	m.SetMultilineMatch(true)
+	m.SetQuery(`^BEGIN\n.*\nEND$`)
+
+	if got, want := m.FilteredIndices(), []int{1, 2, 3, 7, 8, 9}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+	assertScreen(t, m, 10, 6, "BEGIN", "body", "END", "BEGIN", "more", "END")
+
+	// lines written later can complete a match
+	m.Write("BEGIN\nlast\n")
+	if got, want := m.FilteredCount(), 6; got != want {
+		t.Errorf("FilteredCount() = %d, want %d", got, want)
+	}
+	m.Write("END\n")
+	if got, want := m.FilteredIndices(), []int{1, 2, 3, 7, 8, 9, 10, 11, 12}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+
+	m.SetMultilineMatch(false)
+	if got, want := m.FilteredCount(), 0; got != want {
+		t.Errorf("FilteredCount() = %d, want %d line by line", got, want)
+	}
+}
+
+func TestMultilineWindows(t *testing.T) {
+	m := New()
+	for i := range 3 * multilineWindow {
+		m.WriteLine(fmt.Sprint(i))
+	}
+	m.SetMultilineMatch(true)
+
+	// matches across the boundaries between windows are found, once
+	start := multilineWindow - multilineSpan - 1
+	m.SetQuery(fmt.Sprintf(`%d\n%d\n%d\n`, start, start+1, start+2))
+	if got, want := m.FilteredIndices(), []int{start, start + 1, start + 2}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+	start = multilineWindow - 2
+	m.SetQuery(fmt.Sprintf(`^%d\n%d\n%d$`, start, start+1, start+2))
+	if got, want := m.FilteredIndices(), []int{start, start + 1, start + 2}; !slices.Equal(got, want) {
+		t.Errorf("FilteredIndices() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package logview
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// monochromeHighlight marks search matches without color.
+var monochromeHighlight = lipgloss.NewStyle().Reverse(true)
+
+// SetNoColor sets whether output is monochrome: every color is removed,
+// whether it came from a style, a colorizer, or the log itself, leaving
+// attributes like bold and reverse video. Search matches are shown in
+// reverse video instead. By default, output is monochrome if the NO_COLOR
+// environment variable is set.
+func (m *Model) SetNoColor(noColor bool) { m.noColor = noColor }
+
+// noColorFromEnv reports whether NO_COLOR asks for monochrome output. See
+// https://no-color.org.
+func noColorFromEnv() bool { return os.Getenv("NO_COLOR") != "" }
+
+// highlightStyle returns the style for search matches.
+func (m *Model) highlightStyle() lipgloss.Style {
+	if m.noColor {
+		return monochromeHighlight
+	}
+	return highlight
+}
+
+// stripColors removes the color parameters from every SGR sequence in s,
+// dropping sequences that are left with nothing to do.
+func stripColors(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	return sgrRe.ReplaceAllStringFunc(s, func(seq string) string {
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		if len(params) == 1 && params[0] == "" {
+			// a bare reset
+			return seq
+		}
+
+		var kept []string
+		for i := 0; i < len(params); i++ {
+			n, err := strconv.Atoi(params[i])
+			switch {
+			case err != nil:
+				kept = append(kept, params[i])
+			case n == 38 || n == 48 || n == 58:
+				// extended colors take more parameters: 5;n or 2;r;g;b
+				if i+1 < len(params) && params[i+1] == "5" {
+					i += 2
+				} else if i+1 < len(params) && params[i+1] == "2" {
+					i += 4
+				}
+			case 30 <= n && n <= 49, 90 <= n && n <= 107, n == 59:
+			default:
+				kept = append(kept, params[i])
+			}
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(kept, ";") + "m"
+	})
+}
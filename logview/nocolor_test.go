@@ -0,0 +1,45 @@
+package logview
+
+import (
+	"regexp"
+	"testing"
+)
+
+// colorRe matches SGR sequences setting a color.
+var colorRe = regexp.MustCompile(`\x1b\[[0-9;]*\b(3[0-9]|4[0-9]|9[0-7]|10[0-7])\b[0-9;]*m`)
+
+func TestNoColor(t *testing.T) {
+	m := New(WithoutStatusbar)
+	m.Write("\x1b[31mred\x1b[0m and \x1b[1;38;5;208morange\x1b[0m\n\x1b[48;2;1;2;3mbackground\x1b[m\n")
+	m.SetQuery("r")
+	m.SetNoColor(true)
+
+	out := m.RenderLog(30, 2)
+	if colorRe.MatchString(out) {
+		t.Errorf("RenderLog() = %q, want no colors", out)
+	}
+	if !regexp.MustCompile(`\x1b\[1m`).MatchString(out) {
+		t.Errorf("RenderLog() = %q, want bold kept", out)
+	}
+
+	// matches are still marked
+	if !m.highlightStyle().GetReverse() {
+		t.Error("matches aren't highlighted in reverse video")
+	}
+}
+
+func TestStripColors(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"plain", "plain"},
+		{"\x1b[31mred\x1b[0m", "red\x1b[0m"},
+		{"\x1b[1;31mbold\x1b[m", "\x1b[1mbold\x1b[m"},
+		{"\x1b[38;5;208;4mx", "\x1b[4mx"},
+		{"\x1b[7;48;2;1;2;3mx", "\x1b[7mx"},
+		{"\x1b[39;49mx", "x"},
+	}
+	for _, tt := range tests {
+		if got := stripColors(tt.s); got != tt.want {
+			t.Errorf("stripColors(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package logview
+
+const raceEnabled = false
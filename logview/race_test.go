@@ -0,0 +1,8 @@
+//go:build race
+
+package logview
+
+// raceEnabled reports whether the race detector is on, which makes
+// allocation counts meaningless: sync.Pool, which regexp relies on, drops
+// what's put in it at random.
+const raceEnabled = true
@@ -0,0 +1,61 @@
+package logview
+
+import "time"
+
+// lineRateWindow is the number of seconds the line rate is averaged over.
+const lineRateWindow = 5
+
+// lineRate counts lines written over a sliding window of seconds.
+type lineRate struct {
+	// counts holds the number of lines written in each of the last few
+	// seconds, indexed by the second modulo lineRateWindow. latest is the
+	// newest second counted.
+	counts [lineRateWindow]int
+	latest int64
+}
+
+func (r *lineRate) add(now time.Time, lines int) {
+	sec := now.Unix()
+	r.advance(sec)
+	r.counts[sec%lineRateWindow] += lines
+}
+
+// advance clears the counts of seconds that have left the window by sec.
+func (r *lineRate) advance(sec int64) {
+	if sec <= r.latest {
+		return
+	}
+	for s := max(r.latest+1, sec-lineRateWindow+1); s <= sec; s++ {
+		r.counts[s%lineRateWindow] = 0
+	}
+	r.latest = sec
+}
+
+func (r *lineRate) perSecond(now time.Time) float64 {
+	r.advance(now.Unix())
+	var total int
+	for _, n := range r.counts {
+		total += n
+	}
+	return float64(total) / lineRateWindow
+}
+
+// SetShowLineRate sets whether the rate at which lines are being written is
+// tracked, and shown in the statusbar while tailing.
+func (m *Model) SetShowLineRate(show bool) {
+	if show && m.rate == nil {
+		m.rate = &lineRate{}
+	} else if !show {
+		m.rate = nil
+	}
+}
+
+// LineRate returns the number of lines written per second, averaged over
+// the last few seconds. It's only tracked once enabled with
+// [Model.SetShowLineRate]; until then, it's zero.
+func (m *Model) LineRate() float64 {
+	if m.rate == nil {
+		return 0
+	}
+	return m.rate.perSecond(time.Now())
+}
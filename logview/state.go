@@ -0,0 +1,202 @@
+package logview
+
+import (
+	"regexp"
+	"slices"
+)
+
+// ViewState is a snapshot of how the log is being viewed: what it's
+// filtered on and how, and where it's scrolled to. It can be saved as JSON
+// along with a session, and restored with [Model.RestoreViewState] to show
+// the same lines the same way, given the same log.
+type ViewState struct {
+	Query       string      `json:"query,omitempty"`
+	Find        string      `json:"find,omitempty"`
+	AnchorStart bool        `json:"anchorStart,omitempty"`
+	AnchorEnd   bool        `json:"anchorEnd,omitempty"`
+	SearchScope SearchScope `json:"searchScope,omitempty"`
+	Multiline   bool        `json:"multiline,omitempty"`
+	POSIX       bool        `json:"posix,omitempty"`
+
+	// Include and Exclude are the patterns of the filter rule, if any, set
+	// by [Model.SetFilterRule].
+	Include string `json:"include,omitempty"`
+	Exclude string `json:"exclude,omitempty"`
+
+	// FieldKey and FieldPattern are the field filter, if any, set by
+	// [Model.FilterByField].
+	FieldKey     string `json:"fieldKey,omitempty"`
+	FieldPattern string `json:"fieldPattern,omitempty"`
+
+	Streams       []Stream `json:"streams,omitempty"`
+	ContextBefore int      `json:"contextBefore,omitempty"`
+	ContextAfter  int      `json:"contextAfter,omitempty"`
+
+	HardWrap bool `json:"hardWrap,omitempty"`
+	Reverse  bool `json:"reverse,omitempty"`
+
+	// Top is the position in the view of the line at the top of the
+	// viewport, and RowOffset how many of its rows are scrolled out of
+	// view. They're ignored while Tailing.
+	Top       int  `json:"top"`
+	RowOffset int  `json:"rowOffset,omitempty"`
+	Tailing   bool `json:"tailing,omitempty"`
+
+	// Cursor is the position in the view of the current line.
+	Cursor int `json:"cursor"`
+}
+
+// ViewState returns a snapshot of how the log is being viewed.
+func (m *Model) ViewState() ViewState {
+	state := ViewState{
+		Query:         m.Query(),
+		Find:          m.Find(),
+		AnchorStart:   m.anchorStart,
+		AnchorEnd:     m.anchorEnd,
+		SearchScope:   m.searchScope,
+		Multiline:     m.multilineMatch,
+		POSIX:         m.posixMatch,
+		Streams:       slices.Clone(m.streamFilter),
+		ContextBefore: m.contextBefore,
+		ContextAfter:  m.contextAfter,
+		HardWrap:      m.shouldHardwrap,
+		Reverse:       m.reverse,
+	}
+	if m.filterInclude != nil {
+		state.Include = m.filterInclude.String()
+	}
+	if m.filterExclude != nil {
+		state.Exclude = m.filterExclude.String()
+	}
+	if m.fieldRe != nil {
+		state.FieldKey, state.FieldPattern = m.fieldKey, m.fieldRe.String()
+	}
+	mark := m.scrollMark()
+	state.Top, state.RowOffset, state.Tailing, state.Cursor = mark.top, mark.rowOffset, mark.tailing, mark.cursor
+	return state
+}
+
+// RestoreViewState views the log as it was when state was taken by
+// [Model.ViewState]. Filter rule and field patterns that don't compile are
+// left out.
+func (m *Model) RestoreViewState(state ViewState) {
+	m.anchorStart, m.anchorEnd = state.AnchorStart, state.AnchorEnd
+	m.searchScope = state.SearchScope
+	m.multilineMatch = state.Multiline
+	m.posixMatch = state.POSIX
+	m.filterInclude, m.filterExclude = compileOrNil(state.Include), compileOrNil(state.Exclude)
+	m.fieldKey, m.fieldRe = state.FieldKey, compileOrNil(state.FieldPattern)
+	m.streamFilter = nil
+	if len(state.Streams) > 0 {
+		m.streamFilter = slices.Clone(state.Streams)
+	}
+	m.contextBefore, m.contextAfter = max(0, state.ContextBefore), max(0, state.ContextAfter)
+	m.shouldHardwrap = state.HardWrap
+	// positions are restored as they were, so they aren't flipped like
+	// SetReverse does
+	m.reverse = state.Reverse
+
+	m.SetQuery(state.Query)
+	m.SetFind(state.Find)
+	m.restoreScroll(scrollMark{state.Top, state.RowOffset, state.Tailing, state.Cursor})
+}
+
+// scrollMark is where the viewport is scrolled to, and the current line.
+type scrollMark struct {
+	top, rowOffset int
+	tailing        bool
+	cursor         int
+}
+
+// scrollMark returns where the viewport is scrolled to.
+func (m *Model) scrollMark() scrollMark {
+	mark := scrollMark{cursor: m.cursor}
+	mark.top, mark.tailing = m.ScrollPosition()
+	if !mark.tailing {
+		mark.rowOffset = m.topRowOffset()
+	}
+	return mark
+}
+
+// restoreScroll scrolls the viewport back to mark, as far as the view
+// still allows.
+func (m *Model) restoreScroll(mark scrollMark) {
+	m.rowOffset = 0
+	if mark.tailing {
+		m.scrollPosition = -1
+	} else {
+		m.scrollPosition = clamp(0, m.maxScroll(), mark.top)
+		if mark.rowOffset > 0 && m.viewLen() > 0 {
+			m.rowOffset, m.rowOffsetLine = mark.rowOffset, m.viewIndex(m.scrollPosition)
+		}
+	}
+	m.cursor = clamp(0, max(0, m.viewLen()-1), mark.cursor)
+}
+
+// maxRememberedScrolls is how many queries' scroll positions are
+// remembered with [Model.SetRememberScrollPerFilter].
+const maxRememberedScrolls = 16
+
+// rememberedScroll is where the viewport was scrolled to with query
+// applied.
+type rememberedScroll struct {
+	query string
+	mark  scrollMark
+}
+
+// SetRememberScrollPerFilter sets whether each query remembers where the
+// viewport was scrolled to while it was applied, and scrolls back there
+// when it's applied again, rather than staying put. The positions of the
+// last 16 queries are remembered, including the empty one.
+func (m *Model) SetRememberScrollPerFilter(remember bool) {
+	m.rememberScroll = remember
+	m.rememberedScrolls, m.pendingScroll = nil, nil
+}
+
+// saveScroll remembers where the viewport is scrolled to under query, as
+// the most recently used.
+func (m *Model) saveScroll(query string) {
+	if !m.rememberScroll {
+		return
+	}
+	m.rememberedScrolls = slices.DeleteFunc(m.rememberedScrolls, func(r rememberedScroll) bool {
+		return r.query == query
+	})
+	if len(m.rememberedScrolls) >= maxRememberedScrolls {
+		m.rememberedScrolls = slices.Delete(m.rememberedScrolls, 0, 1)
+	}
+	m.rememberedScrolls = append(m.rememberedScrolls, rememberedScroll{query, m.scrollMark()})
+}
+
+// recallScroll scrolls back to where the viewport was with query applied,
+// if that's remembered. If the query is still being searched for in the
+// background, that's done once the results are in.
+func (m *Model) recallScroll(query string) {
+	if !m.rememberScroll {
+		return
+	}
+	for _, r := range m.rememberedScrolls {
+		if r.query != query {
+			continue
+		}
+		if m.pendingRe != nil {
+			m.pendingScroll = &r.mark
+		} else {
+			m.restoreScroll(r.mark)
+		}
+		return
+	}
+}
+
+// compileOrNil compiles pattern, or returns nil if it's empty or doesn't
+// compile.
+func compileOrNil(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,122 @@
+package logview
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func newStateModel() *Model {
+	m := New(WithoutStatusbar)
+	for i := range 50 {
+		level := "info"
+		if i%3 == 0 {
+			level = "error"
+		}
+		m.WriteLine(fmt.Sprintf(`{"n":%d,"level":"%s","req":"GET /%d"}`, i, level, i))
+	}
+	m.WriteStream(StreamStderr, "oops\n")
+	return m
+}
+
+func TestViewStateRoundTrip(t *testing.T) {
+	m := newStateModel()
+	m.SetRegexpSyntax(true)
+	m.SetAnchor(true, false)
+	m.SetQuery(`{"n":\d`)
+	m.SetFind("GET")
+	m.SetFilterRule(regexp.MustCompile("GET"), regexp.MustCompile("/1"))
+	if err := m.FilterByField("level", "error"); err != nil {
+		t.Fatal(err)
+	}
+	m.SetStreamFilter(StreamStdout)
+	m.SetFilterContext(0, 1)
+	m.RenderAt(40, 4)
+	m.ScrollTo(3)
+	press(m, "j")
+	want := m.RenderAt(40, 4)
+	if got := m.FilteredCount(); got < 8 {
+		t.Fatalf("FilteredCount() = %d, want enough lines to scroll", got)
+	}
+
+	data, err := json.Marshal(m.ViewState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state ViewState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	restored := newStateModel()
+	restored.RestoreViewState(state)
+
+	if got := restored.RenderAt(40, 4); got != want {
+		t.Errorf("restored screen =\n%s\nwant\n%s", got, want)
+	}
+	if got := restored.ViewState(); !reflect.DeepEqual(got, m.ViewState()) {
+		t.Errorf("restored ViewState() = %+v, want %+v", got, m.ViewState())
+	}
+	if got, want := restored.FilteredIndices(), m.FilteredIndices(); !slices.Equal(got, want) {
+		t.Errorf("restored FilteredIndices() = %v, want %v", got, want)
+	}
+}
+
+func TestViewStateTailing(t *testing.T) {
+	m := newStateModel()
+	m.SetQuery("error")
+	state := m.ViewState()
+	if !state.Tailing {
+		t.Fatal("ViewState().Tailing = false for a new model")
+	}
+
+	// a restored view keeps tailing as lines are written
+	restored := newStateModel()
+	restored.RestoreViewState(state)
+	restored.WriteLine(`{"n":50,"level":"error"}`)
+	assertScreen(t, restored, 30, 1, `{"n":50,"level":"error"}`)
+
+	// a bad pattern is left out, rather than filtering everything
+	state.Include = "("
+	restored.RestoreViewState(state)
+	if restored.filterInclude != nil {
+		t.Errorf("restored include = %v, want none", restored.filterInclude)
+	}
+}
+
+func TestRememberScrollPerFilter(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	for i := range 100 {
+		m.WriteLine(fmt.Sprintf("%d %s", i, []string{"even", "odd"}[i%2]))
+	}
+	m.SetRememberScrollPerFilter(true)
+
+	m.SetQuery("even")
+	m.ScrollTo(10)
+	assertScreen(t, m, 10, 1, "20 even")
+	m.SetQuery("odd")
+	m.ScrollTo(20)
+	assertScreen(t, m, 10, 1, "41 odd")
+
+	// switching back scrolls to where each query was left
+	m.SetQuery("even")
+	assertScreen(t, m, 10, 1, "20 even")
+	m.SetQuery("odd")
+	assertScreen(t, m, 10, 1, "41 odd")
+
+	// including when it's typed, and searched for in the background
+	m.OpenSearch()
+	m.Update(m.TypeSearch("even")())
+	m.CloseSearch(true)
+	assertScreen(t, m, 10, 1, "20 even")
+
+	// only so many queries are remembered; one that isn't stays put
+	for i := range maxRememberedScrolls {
+		m.SetQuery(fmt.Sprint(i))
+	}
+	m.ScrollTo(0)
+	m.SetQuery("odd")
+	assertScreen(t, m, 10, 1, "1 odd")
+}
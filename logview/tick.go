@@ -0,0 +1,45 @@
+package logview
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tickMsg asks the model to re-render, so that time-based parts of the
+// statusbar, like the line rate, stay current.
+type tickMsg struct {
+	// gen is the tick generation the tick belongs to; if the interval has
+	// changed since, it's stale.
+	gen uint64
+}
+
+// SetTickInterval sets how often the model re-renders on its own, so that
+// time-based parts of the statusbar, like the line rate, update even when
+// nothing else happens. Ticking starts from [Model.Init], or from the
+// returned command when the model is already running. Zero, the default,
+// stops ticking.
+func (m *Model) SetTickInterval(interval time.Duration) tea.Cmd {
+	m.tickInterval = max(0, interval)
+	m.tickGen++
+	return m.tick()
+}
+
+// tick returns a command that emits the next tick, or nil if ticking is
+// disabled.
+func (m *Model) tick() tea.Cmd {
+	if m.tickInterval == 0 {
+		return nil
+	}
+	gen := m.tickGen
+	return tea.Tick(m.tickInterval, func(time.Time) tea.Msg { return tickMsg{gen} })
+}
+
+func (m *Model) handleTick(msg tickMsg) tea.Cmd {
+	// a tick from before the interval changed is dropped, so that there's
+	// only ever one running
+	if msg.gen != m.tickGen {
+		return nil
+	}
+	return m.tick()
+}
@@ -0,0 +1,143 @@
+package logview
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// TimeDisplay is how the timestamps of lines are shown in the gutter.
+type TimeDisplay int
+
+const (
+	// TimeHidden doesn't show timestamps.
+	TimeHidden TimeDisplay = iota
+	// TimeAbsolute shows the time of day each line was logged.
+	TimeAbsolute
+	// TimeSinceStart shows the time elapsed since the first line, like
+	// `ts -s`.
+	TimeSinceStart
+	// TimeSincePrev shows the time elapsed since the previous line, like
+	// `ts -i`, which makes slow gaps between lines stand out.
+	TimeSincePrev
+)
+
+// timeGutterWidth is the width of the timestamp column, including a space
+// to separate it from what follows.
+const timeGutterWidth = 13
+
+// maxTimeLookback bounds how many lines back TimeSincePrev looks for the
+// previous line with a timestamp.
+const maxTimeLookback = 1000
+
+// timestampRe matches a timestamp at the start of a line, optionally in
+// brackets: an ISO 8601 date and time, a syslog-style date and time, or a
+// time of day.
+var timestampRe = regexp.MustCompile(`^\[?(` +
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?` +
+	`|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}(?:[.,]\d+)?` +
+	`|\d{2}:\d{2}:\d{2}(?:[.,]\d+)?)`)
+
+// timestampLayouts are the layouts tried when parsing what timestampRe
+// matched. Fractional seconds are accepted by all of them.
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05",
+	time.Stamp,
+	time.TimeOnly,
+}
+
+// SetTimeDisplay sets how the gutter shows when each line was logged,
+// according to the timestamp at its start. Lines without one show nothing.
+func (m *Model) SetTimeDisplay(mode TimeDisplay) { m.timeDisplay = mode }
+
+// lineTime parses the timestamp at the start of the line at index i.
+func (m *Model) lineTime(i int) (time.Time, bool) {
+	line := escapeRe.ReplaceAllString(m.line(i), "")
+	match := timestampRe.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, match[1]); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeLabel returns the gutter label for the time of the line at index i.
+func (m *Model) timeLabel(i int) string {
+	if m.timeDisplay == TimeHidden {
+		return ""
+	}
+	t, ok := m.lineTime(i)
+	if !ok {
+		return ""
+	}
+
+	switch m.timeDisplay {
+	case TimeAbsolute:
+		return t.Format("15:04:05.000")
+	case TimeSinceStart:
+		if start, ok := m.startTime(); ok {
+			return formatElapsed(elapsed(t, start))
+		}
+	case TimeSincePrev:
+		for j := i - 1; j >= max(0, i-maxTimeLookback); j-- {
+			if prev, ok := m.lineTime(j); ok {
+				return formatElapsed(elapsed(t, prev))
+			}
+		}
+		return formatElapsed(0)
+	}
+	return ""
+}
+
+// startTime returns the time of the first line with a timestamp.
+func (m *Model) startTime() (time.Time, bool) {
+	if m.startTimeFound {
+		return m.firstTime, true
+	}
+	for ; m.startTimeSearched < m.lineCount(); m.startTimeSearched++ {
+		if t, ok := m.lineTime(m.startTimeSearched); ok {
+			m.firstTime, m.startTimeFound = t, true
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// elapsed returns the time from since to t. Timestamps without a year,
+// like syslog's or a bare time of day, are parsed as if in year 0, so if
+// only one of them has a year, only their times of day are compared.
+func elapsed(t, since time.Time) time.Duration {
+	if (t.Year() == 0) == (since.Year() == 0) {
+		return t.Sub(since)
+	}
+	clock := func(t time.Time) time.Duration {
+		return t.Sub(t.Truncate(24 * time.Hour))
+	}
+	return clock(t) - clock(since)
+}
+
+// formatElapsed formats d to fit the timestamp column, like "+1.500s" or
+// "+2m03.250s".
+func formatElapsed(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%s%.3fs", sign, d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%s%dm%06.3fs", sign, int(d.Minutes()), (d % time.Minute).Seconds())
+	default:
+		return fmt.Sprintf("%s%dh%02dm%02ds", sign, int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+}
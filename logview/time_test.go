@@ -0,0 +1,85 @@
+package logview
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeDisplay(t *testing.T) {
+	m := New(WithoutStatusbar, WithStartAtHead)
+	m.Write("2024-01-02T15:04:05Z start\n" +
+		"continued\n" +
+		"[2024-01-02 15:04:06.500] next\n" +
+		"2024-01-02T15:06:10.250+00:00 slow\n")
+
+	tests := []struct {
+		mode TimeDisplay
+		want []string
+	}{
+		{TimeHidden, []string{"", "", "", ""}},
+		{TimeAbsolute, []string{"15:04:05.000", "", "15:04:06.500", "15:06:10.250"}},
+		{TimeSinceStart, []string{"+0.000s", "", "+1.500s", "+2m05.250s"}},
+		{TimeSincePrev, []string{"+0.000s", "", "+1.500s", "+2m03.750s"}},
+	}
+	for _, tt := range tests {
+		m.SetTimeDisplay(tt.mode)
+		for i, want := range tt.want {
+			if got := m.timeLabel(i); got != want {
+				t.Errorf("mode %d: timeLabel(%d) = %q, want %q", tt.mode, i, got, want)
+			}
+		}
+	}
+
+	m.SetTimeDisplay(TimeSincePrev)
+	assertScreen(t, m, 50, 2,
+		"+0.000s      2024-01-02T15:04:05Z start",
+		"             continued",
+	)
+}
+
+func TestLineTime(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"2024-01-02T15:04:05.123Z x", "2024-01-02T15:04:05.123Z", true},
+		{"2024-01-02T15:04:05+0100 x", "2024-01-02T15:04:05+01:00", true},
+		{"2024-01-02 15:04:05,250 x", "2024-01-02T15:04:05.25Z", true},
+		{"Jan  2 15:04:05 host x", "0000-01-02T15:04:05Z", true},
+		{"15:04:05 x", "0000-01-01T15:04:05Z", true},
+		{"\x1b[2m15:04:05\x1b[0m x", "0000-01-01T15:04:05Z", true},
+		{"no time here", "", false},
+	}
+	for _, tt := range tests {
+		m := New()
+		m.WriteLine(tt.line)
+		got, ok := m.lineTime(0)
+		if ok != tt.ok || (ok && got.Format(time.RFC3339Nano) != tt.want) {
+			t.Errorf("lineTime(%q) = %s, %v, want %s, %v", tt.line, got.Format(time.RFC3339Nano), ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestElapsed(t *testing.T) {
+	dated := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	clock := time.Date(0, 1, 1, 15, 0, 30, 0, time.UTC)
+	if got, want := elapsed(clock, dated), 30*time.Second; got != want {
+		t.Errorf("elapsed() = %v, want %v comparing times of day", got, want)
+	}
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Millisecond, "+1.500s"},
+		{-250 * time.Millisecond, "-0.250s"},
+		{2*time.Minute + 3250*time.Millisecond, "+2m03.250s"},
+		{26*time.Hour + 5*time.Minute + 9*time.Second, "+26h05m09s"},
+	}
+	for _, tt := range tests {
+		if got := formatElapsed(tt.d); got != tt.want {
+			t.Errorf("formatElapsed(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package logview
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// tabWidth is how many cells a tab takes up, matching how lipgloss renders
+// it.
+const tabWidth = 4
+
+// leadingEscapeRe matches an escape sequence at the start of a string.
+var leadingEscapeRe = regexp.MustCompile("^" + escapeRe.String())
+
+// forEachCluster calls fn with each grapheme cluster of s, like a character
+// with its combining marks or an emoji sequence, and the number of cells it
+// takes up. Escape sequences are passed whole, taking up no cells.
+func forEachCluster(s string, fn func(cluster string, width int)) {
+	state := -1
+	for s != "" {
+		if seq := leadingEscapeRe.FindString(s); seq != "" {
+			fn(seq, 0)
+			s, state = s[len(seq):], -1
+			continue
+		}
+
+		// clusters never span an escape sequence
+		text := s
+		if i := strings.IndexByte(s[1:], '\x1b'); i >= 0 {
+			text = s[:i+1]
+		}
+		s = s[len(text):]
+		for text != "" {
+			var cluster string
+			var width int
+			cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+			if cluster == "\t" {
+				width = tabWidth
+			}
+			fn(cluster, width)
+		}
+	}
+}
+
+// stringWidth returns the number of cells s takes up on screen.
+func stringWidth(s string) int {
+	width := 0
+	forEachCluster(s, func(_ string, w int) { width += w })
+	return width
+}
+
+// hardwrap breaks s into rows no wider than width, without splitting any
+// grapheme cluster. As in reflow, tabs are expanded to spaces, and spaces
+// at the start of a row that was broken are dropped.
+func hardwrap(s string, width int) string {
+	if width <= 0 || stringWidth(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	rowWidth, broken := 0, false
+	forEachCluster(s, func(cluster string, w int) {
+		if cluster == "\n" {
+			// s may already be wrapped, as by wordwrap
+			b.WriteByte('\n')
+			rowWidth, broken = 0, false
+			return
+		}
+		if rowWidth > 0 && rowWidth+w > width {
+			b.WriteByte('\n')
+			rowWidth, broken = 0, true
+		}
+		if rowWidth == 0 && broken && (cluster == " " || cluster == "\t") {
+			return
+		}
+		if cluster == "\t" {
+			cluster = strings.Repeat(" ", tabWidth)
+		}
+		b.WriteString(cluster)
+		rowWidth += w
+	})
+	return b.String()
+}
+
+// truncateWidth cuts s down to at most width cells, without splitting any
+// grapheme cluster. If styling is active where it's cut, it's reset.
+func truncateWidth(s string, width int) string {
+	if stringWidth(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	rowWidth, styled, full := 0, false, false
+	forEachCluster(s, func(cluster string, w int) {
+		if full || rowWidth+w > width {
+			full = true
+			return
+		}
+		if sgrRe.MatchString(cluster) {
+			styled = cluster != "\x1b[0m" && cluster != "\x1b[m"
+		}
+		b.WriteString(cluster)
+		rowWidth += w
+	})
+	if styled {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
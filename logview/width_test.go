@@ -0,0 +1,89 @@
+package logview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"abc", 3},
+		{"日本語", 6},
+		{"e\u0301", 1},
+		{"👍\U0001f3fd", 2},
+		{"👨\u200d👩\u200d👧", 2},
+		{"a\tb", 2 + tabWidth},
+		{"\x1b[31m日本\x1b[0m", 4},
+	}
+	for _, tt := range tests {
+		if got := stringWidth(tt.s); got != tt.want {
+			t.Errorf("stringWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHardwrap(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"abcdef", 3, "abc\ndef"},
+		// a wide character that doesn't fit moves to the next row
+		{"ab日本", 3, "ab\n日\n本"},
+		{"e\u0301e\u0301e\u0301", 2, "e\u0301e\u0301\ne\u0301"},
+		{"a👨\u200d👩\u200d👧b", 2, "a\n👨\u200d👩\u200d👧\nb"},
+		{"ab cd", 2, "ab\ncd"},
+		{"\x1b[1mabc\x1b[0m", 2, "\x1b[1mab\nc\x1b[0m"},
+		// already wrapped rows aren't broken again
+		{"abc\nd", 3, "abc\nd"},
+	}
+	for _, tt := range tests {
+		if got := hardwrap(tt.s, tt.width); got != tt.want {
+			t.Errorf("hardwrap(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc"},
+		{"日本語", 5, "日本"},
+		{"e\u0301e\u0301e\u0301", 2, "e\u0301e\u0301"},
+		{"a👍\U0001f3fdb", 2, "a"},
+		{"\x1b[31mabc", 2, "\x1b[31mab\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWideCharacters(t *testing.T) {
+	lines := "日本語のログ行です\ncafe\u0301 ok\nemoji 👨\u200d👩\u200d👧👍\U0001f3fd done\n"
+	for _, wrap := range []bool{false, true} {
+		m := New(WithoutStatusbar, WithStartAtHead)
+		if wrap {
+			m = WithSoftWrap(m)
+		}
+		m.SetRelativeLineNumbers(true)
+		m.Write(lines)
+		for width := 5; width <= 20; width++ {
+			for _, row := range strings.Split(m.RenderAt(width, 10), "\n") {
+				if w := lipgloss.Width(row); w != width {
+					t.Errorf("wrap %v, width %d: row %q is %d cells wide", wrap, width, row, w)
+				}
+			}
+		}
+	}
+}
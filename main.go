@@ -4,94 +4,36 @@ package main
 // component library.
 
 import (
-	"bufio"
+	"context"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"syscall"
-	"time"
 
 	"github.com/cgsdev0/logfilter/logview"
 	tea "github.com/charmbracelet/bubbletea"
 	"golang.org/x/sys/unix"
 )
 
-type Sink func(string)
-
-func (sink Sink) tailStdin() error {
-	sc := bufio.NewScanner(os.Stdin)
-	defer os.Stdin.Close()
-	for {
-		if !sc.Scan() {
-			if err := sc.Err(); err != nil {
-				return err
-			} else {
-				sink("EOF\n")
-			}
-			break
-		}
-		sink(sc.Text() + "\n")
-	}
-	return nil
-}
-
-func (sink Sink) tailFile(filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	reader := bufio.NewReader(file)
-	for {
-		bs, err := io.ReadAll(reader)
-		if err != nil {
-			return err
-		}
-		sink(string(bs))
-		time.Sleep(time.Millisecond * 32)
-	}
-}
-
 func main() {
 	flag.Parse()
 
-	program := tea.NewProgram(newScroll(),
+	ctx, stopFollowing := context.WithCancel(context.Background())
+	model := newScroll(ctx, flag.Arg(0))
+	program := tea.NewProgram(model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion())
 
-	filename := flag.Arg(0)
-
-	sinkErr := make(chan error)
-	sink := Sink(func(s string) { program.Send(writeMsg(s)) })
-	go func() {
-		switch filename {
-		case "-", "":
-			sinkErr <- sink.tailStdin()
-		default:
-			sinkErr <- sink.tailFile(filename)
-		}
-	}()
-
-	programErr := make(chan error)
-	go func() {
-		_, err := program.Run()
-		programErr <- err
-	}()
-
-	select {
-	case err := <-sinkErr:
-		program.Send(tea.Quit())
-		<-programErr
+	_, err := program.Run()
+	stopFollowing()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := model.err; err != nil {
 		fmt.Println(err)
 		os.Exit(1)
-	case err := <-programErr:
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
 	}
 
 	os.Stdin.Close()
@@ -113,30 +55,38 @@ func main() {
 	os.Exit(0)
 }
 
-type (
-	writeMsg string
-)
-
 type scroll struct {
 	logview *logview.Model
+	follow  tea.Cmd
+
+	// err is why following the log failed, if it did
+	err error
 }
 
-func newScroll() *scroll {
-	return &scroll{logview.WithSoftWrap(logview.New())}
+func newScroll(ctx context.Context, filename string) *scroll {
+	t := &scroll{logview: logview.WithSoftWrap(logview.New())}
+	switch filename {
+	case "-", "":
+		// stdin ending isn't an error; keep showing what we read
+		t.follow = logview.FollowReader(ctx, os.Stdin)
+	default:
+		t.follow = logview.FollowFile(ctx, filename)
+	}
+	return t
 }
 
 var _ tea.Model = &scroll{}
 
-func (t *scroll) Init() tea.Cmd { return t.logview.Init() }
+func (t *scroll) Init() tea.Cmd { return tea.Batch(t.logview.Init(), t.follow) }
 
 func (t *scroll) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		t.logview.SetDimensions(msg.Width, msg.Height)
 		return t, nil
-	case writeMsg:
-		t.logview.Write(string(msg))
-		return t, nil
+	case logview.FollowErrMsg:
+		t.err = msg.Err
+		return t, tea.Quit
 	}
 	model, cmd := t.logview.Update(msg)
 	t.logview = model.(*logview.Model)